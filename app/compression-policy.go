@@ -0,0 +1,125 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+var defaultUncompressibleTypes = []string{
+	"image/*",
+	"audio/*",
+	"video/*",
+	"font/woff",
+	"font/woff2",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"application/x-bzip2",
+	"application/x-xz",
+	"application/x-7z-compressed",
+	"application/vnd.rar",
+	"application/zstd",
+}
+
+// Extensions of files which content is compressed already, used for files
+// which type can't be told by Content-Type.
+var compressedFileExtensions = map[string]bool{
+	".gz": true, ".tgz": true, ".zip": true, ".bz2": true, ".xz": true,
+	".7z": true, ".rar": true, ".zst": true, ".png": true, ".jpg": true,
+	".jpeg": true, ".gif": true, ".webp": true, ".avif": true, ".mp3": true,
+	".mp4": true, ".webm": true, ".woff": true, ".woff2": true,
+}
+
+// CompressionPolicy decides which responses are worth compressing, so CPU
+// isn't wasted on bodies which won't get any smaller.
+type CompressionPolicy struct {
+	// bodies with known length below this size are sent as is
+	minSize int
+	// when not empty, only these content types are compressed
+	allowedTypes []string
+	// these content types are never compressed
+	deniedTypes []string
+}
+
+// NewCompressionPolicy creates policy from comma separated lists of content
+// types. Types may end with "/*" to match all subtypes. Empty deny list
+// falls back to the list of well known compressed formats.
+func NewCompressionPolicy(minSize int, allowedTypes string, deniedTypes string) *CompressionPolicy {
+	policy := &CompressionPolicy{
+		minSize:      minSize,
+		allowedTypes: splitContentTypes(allowedTypes),
+		deniedTypes:  splitContentTypes(deniedTypes),
+	}
+
+	if len(policy.deniedTypes) == 0 {
+		policy.deniedTypes = defaultUncompressibleTypes
+	}
+
+	return policy
+}
+
+func (policy *CompressionPolicy) ShouldCompress(response *HttpResponse, body IHttpBody) bool {
+	if !canCompress(response) {
+		return false
+	}
+
+	if sizedBody, ok := body.(IHttpBodyDefinedLength); ok && sizedBody.ContentLength() < policy.minSize {
+		return false
+	}
+
+	if fileBody, ok := body.(*HttpFileBody); ok {
+		if compressedFileExtensions[strings.ToLower(filepath.Ext(fileBody.path))] {
+			return false
+		}
+	}
+
+	contentType := mediaType(response.ContentType())
+
+	if matchesContentType(policy.deniedTypes, contentType) {
+		return false
+	}
+
+	return len(policy.allowedTypes) == 0 || matchesContentType(policy.allowedTypes, contentType)
+}
+
+// canCompress reports whether content coding can be applied to the body of
+// response at all, regardless of whether it is worth it.
+func canCompress(response *HttpResponse) bool {
+	// Handler has encoded the body on its own
+	if response.GetHeaders().Has("Content-Encoding") {
+		return false
+	}
+
+	// Ranges refer to bytes of uncompressed content
+	return !response.hasNoBody() && response.StatusCode() != 206 && response.StatusCode() != 416
+}
+
+// mediaType strips parameters (like charset) from content type.
+func mediaType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+func matchesContentType(patterns []string, contentType string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(contentType, prefix+"/") {
+				return true
+			}
+		} else if pattern == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func splitContentTypes(list string) []string {
+	contentTypes := []string{}
+	for _, contentType := range strings.Split(list, ",") {
+		contentType = mediaType(contentType)
+		if contentType != "" {
+			contentTypes = append(contentTypes, contentType)
+		}
+	}
+	return contentTypes
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// fileETag derives entity tag from file metadata, so it changes whenever
+// file is modified without reading its content. Weak tags claim only
+// semantic equivalence of content.
+func fileETag(info os.FileInfo, weak bool) string {
+	etag := fmt.Sprintf("\"%x-%x\"", info.ModTime().UnixNano(), info.Size())
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+func isWeakETag(etag string) bool {
+	return strings.HasPrefix(etag, "W/")
+}
+
+// etagMatches checks whether list of entity tags from If-Match or
+// If-None-Match header contains etag. Strong comparison fails when any of
+// tags is weak, weak comparison ignores weakness (RFC 9110, section 8.8.3.2).
+func etagMatches(header string, etag string, strong bool) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if strong && isWeakETag(etag) {
+		return false
+	}
+
+	opaqueTag := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong && isWeakETag(candidate) {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == opaqueTag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// evaluatePreconditions checks conditional headers of request against the
+// current state of the resource, in order defined by RFC 9110, section 13.2.2.
+// Empty etag means that the resource doesn't exist. Result is the status to
+// respond with instead of processing the request: 304 Not Modified,
+// 412 Precondition Failed, or 0 when request should be processed.
+func evaluatePreconditions(request *HttpRequest, etag string, lastModified time.Time) int {
+	exists := etag != ""
+	isRead := request.method == "GET" || request.method == "HEAD"
+
+	if ifMatch := request.headers.GetList("If-Match"); ifMatch != "" {
+		if !exists || !etagMatches(ifMatch, etag, true) {
+			return 412
+		}
+	} else if date, ok := parseHttpDate(request.GetHeader("If-Unmodified-Since")); ok && exists {
+		if lastModified.Truncate(time.Second).After(date) {
+			return 412
+		}
+	}
+
+	if ifNoneMatch := request.headers.GetList("If-None-Match"); ifNoneMatch != "" {
+		if exists && etagMatches(ifNoneMatch, etag, false) {
+			if isRead {
+				return 304
+			}
+			return 412
+		}
+	} else if date, ok := parseHttpDate(request.GetHeader("If-Modified-Since")); ok && exists && isRead {
+		if !lastModified.Truncate(time.Second).After(date) {
+			return 304
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+)
+
+// IContentEncoder implements one of content codings which can be applied to
+// response body, like gzip. Encoders are registered on the server and chosen
+// according to Accept-Encoding header of the request.
+type IContentEncoder interface {
+	// Name is the content coding token sent in Content-Encoding header
+	Name() string
+	NewWriter(writer io.Writer) (io.WriteCloser, error)
+}
+
+type GzipEncoder struct{}
+
+func (encoder *GzipEncoder) Name() string {
+	return "gzip"
+}
+
+func (encoder *GzipEncoder) NewWriter(writer io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(writer), nil
+}
+
+// DeflateEncoder produces "deflate" coding, which is deflate stream inside
+// zlib container (RFC 9110, section 8.4.1.2).
+type DeflateEncoder struct{}
+
+func (encoder *DeflateEncoder) Name() string {
+	return "deflate"
+}
+
+func (encoder *DeflateEncoder) NewWriter(writer io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriter(writer), nil
+}
+
+// negotiateEncoding picks the encoder with the highest quality accepted by
+// client. Nil encoder means that body should be sent as is (identity coding),
+// which happens also when client prefers identity over all encoders. When
+// neither of encoders nor identity is acceptable, ok is false.
+func negotiateEncoding(accepted []AcepptedEcoding, encoders []IContentEncoder) (encoder IContentEncoder, ok bool) {
+	// Without preferences of client identity is the safest choice
+	if len(accepted) == 0 {
+		return nil, true
+	}
+
+	bestQuality := 0.0
+	for _, candidate := range encoders {
+		quality := encodingQuality(accepted, candidate.Name(), 0)
+		// On equal quality the encoder registered first wins
+		if quality > bestQuality {
+			encoder = candidate
+			bestQuality = quality
+		}
+	}
+
+	// Identity competes with encoders only when client gives it a quality,
+	// and wins only with a higher one
+	if encoder == nil || encodingQuality(accepted, "identity", 0) > bestQuality {
+		// Identity is acceptable unless it is excluded explicitly or via "*;q=0"
+		return nil, !isIdentityRefused(accepted)
+	}
+
+	return encoder, true
+}
+
+// isIdentityRefused reports whether client doesn't accept body sent as is.
+func isIdentityRefused(accepted []AcepptedEcoding) bool {
+	return len(accepted) > 0 && encodingQuality(accepted, "identity", 1) == 0
+}
+
+// encodingQuality returns quality of coding given by client, falling back to
+// quality of "*" and then to defaultQuality when coding is not mentioned.
+func encodingQuality(accepted []AcepptedEcoding, name string, defaultQuality float64) float64 {
+	wildcardQuality := -1.0
+	for _, encoding := range accepted {
+		if encoding.name == name {
+			return encoding.quality
+		}
+		if encoding.name == "*" {
+			wildcardQuality = encoding.quality
+		}
+	}
+
+	if wildcardQuality >= 0 {
+		return wildcardQuality
+	}
+
+	return defaultQuality
+}
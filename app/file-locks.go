@@ -0,0 +1,46 @@
+package main
+
+import "sync"
+
+// fileLocks serializes modifications of files, so that checking state of a
+// file and replacing it happen as one step for concurrent editors. Locks are
+// kept only while somebody holds or waits for them. Zero value is ready to use.
+type fileLocks struct {
+	mutex sync.Mutex
+	locks map[string]*fileLock
+}
+
+type fileLock struct {
+	sync.Mutex
+	// users counts holders and waiters of the lock
+	users int
+}
+
+// lock blocks until lock of file at fullPath is acquired. Returned function
+// releases it.
+func (locks *fileLocks) lock(fullPath string) (unlock func()) {
+	locks.mutex.Lock()
+	if locks.locks == nil {
+		locks.locks = map[string]*fileLock{}
+	}
+	lock := locks.locks[fullPath]
+	if lock == nil {
+		lock = &fileLock{}
+		locks.locks[fullPath] = lock
+	}
+	lock.users++
+	locks.mutex.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		locks.mutex.Lock()
+		defer locks.mutex.Unlock()
+		lock.users--
+		if lock.users == 0 {
+			delete(locks.locks, fullPath)
+		}
+	}
+}
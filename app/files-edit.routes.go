@@ -0,0 +1,323 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// checkFilePreconditions evaluates If-Match, If-None-Match and the date based
+// conditional headers against the current state of file, so that concurrent
+// editors don't overwrite each other's changes. Changes are made under the
+// lock of file, with preconditions checked again. Info is nil when file doesn't
+// exist. When request can't be processed, error response is sent and ok is
+// false.
+func checkFilePreconditions(request *HttpRequest, response *HttpResponse, fullPath string) (info os.FileInfo, ok bool) {
+	info, err := os.Stat(fullPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Failed to stat file: %v", err)
+		response.Status500().Text("File server feature is not available!")
+		return nil, false
+	}
+
+	etag, lastModified := "", time.Time{}
+	if info != nil {
+		if info.IsDir() {
+			response.Status409().Text("Path refers to a directory")
+			return nil, false
+		}
+		etag, lastModified = fileETag(info, *request.server.config.weakETags), info.ModTime()
+	}
+
+	if evaluatePreconditions(request, etag, lastModified) != 0 {
+		response.Status412().Send()
+		return nil, false
+	}
+
+	return info, true
+}
+
+var errContentRangeMismatch = errors.New("length of body doesn't match Content-Range")
+
+// exactLengthReader reads body which has to be exactly remaining bytes long,
+// failing with errContentRangeMismatch otherwise.
+type exactLengthReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (body *exactLengthReader) Read(p []byte) (int, error) {
+	if body.remaining == 0 {
+		// Body has to end right at the declared length
+		var extra [1]byte
+		n, err := body.reader.Read(extra[:])
+		if n > 0 {
+			return 0, errContentRangeMismatch
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > body.remaining {
+		p = p[:body.remaining]
+	}
+	n, err := body.reader.Read(p)
+	body.remaining -= int64(n)
+	if errors.Is(err, io.EOF) {
+		if body.remaining > 0 {
+			return n, errContentRangeMismatch
+		}
+		err = nil
+	}
+	return n, err
+}
+
+// setFileETag sends validators of file state after modification, so that
+// client can make the next change conditional.
+func setFileETag(request *HttpRequest, response *HttpResponse, fullPath string) {
+	if info, err := os.Stat(fullPath); err == nil {
+		response.SetHeader("ETag", fileETag(info, *request.server.config.weakETags))
+		response.SetHeader("Last-Modified", formatHttpDate(info.ModTime()))
+	}
+}
+
+// putFileRoute creates file or replaces its content with request body. It
+// responds with 201 when file is created and with 204 when it is replaced.
+// Preconditions are checked before the body is received, so it isn't sent in
+// vain, and once more under the lock of file right before it is replaced, as
+// someone else may have changed the file in the meantime.
+func putFileRoute(request *HttpRequest, response *HttpResponse) {
+	filePath := request.PathParam("path")
+	fullPath, ok := resolveRequestFilePath(request, response, request.PathParamSegments("path"))
+	if !ok {
+		return
+	}
+
+	if _, ok := checkFilePreconditions(request, response, fullPath); !ok {
+		return
+	}
+
+	body, err := request.LimitedBody(*request.server.config.maxUploadSize)
+	if err != nil {
+		sendUploadError(response, err)
+		return
+	}
+
+	if fullPath, ok = prepareFileDirectory(request, response, filePath, fullPath); !ok {
+		return
+	}
+
+	tempPath, _, err := receiveUploadedFile(filepath.Dir(fullPath), body)
+	if err != nil {
+		sendUploadError(response, err)
+		return
+	}
+	defer os.Remove(tempPath)
+
+	unlock := request.server.fileLocks.lock(fullPath)
+	defer unlock()
+
+	info, ok := checkFilePreconditions(request, response, fullPath)
+	if !ok {
+		return
+	}
+
+	// File which is only to be created must not replace the one created by
+	// anybody else, e.g. by POST, which doesn't take the lock
+	createOnly := strings.TrimSpace(request.GetHeader("If-None-Match")) == "*"
+	err = commitUploadedFile(tempPath, fullPath, !createOnly)
+	if createOnly && errors.Is(err, fs.ErrExist) {
+		response.Status412().Send()
+		return
+	}
+	if err != nil {
+		sendUploadError(response, err)
+		return
+	}
+
+	setFileETag(request, response, fullPath)
+	if info == nil {
+		response.Status(201, "Created").Send()
+	} else {
+		response.Status204().Send()
+	}
+}
+
+// deleteFileRoute removes file. Directories are never removed.
+func deleteFileRoute(request *HttpRequest, response *HttpResponse) {
+	fullPath, ok := resolveRequestFilePath(request, response, request.PathParamSegments("path"))
+	if !ok {
+		return
+	}
+
+	unlock := request.server.fileLocks.lock(fullPath)
+	defer unlock()
+
+	info, ok := checkFilePreconditions(request, response, fullPath)
+	if !ok {
+		return
+	}
+	if info == nil {
+		response.Status404().Text("Requested file not found")
+		return
+	}
+
+	if err := os.Remove(fullPath); err != nil {
+		log.Printf("Failed to remove file: %v", err)
+		response.Status500().Text("Failed to remove file")
+		return
+	}
+
+	response.Status204().Send()
+}
+
+// patchFileRoute updates existing file. Request body is appended to the file,
+// unless Content-Range header (like "bytes 10-19/*") tells which bytes of the
+// file it replaces. Range may start at the end of file, but not past it.
+// Body is received to a temporary file first, and the file is updated under
+// its lock only once the whole body is there, so a failed update leaves file
+// intact and concurrent updates don't lose each other's changes.
+func patchFileRoute(request *HttpRequest, response *HttpResponse) {
+	fullPath, ok := resolveRequestFilePath(request, response, request.PathParamSegments("path"))
+	if !ok {
+		return
+	}
+
+	info, ok := checkFilePreconditions(request, response, fullPath)
+	if !ok {
+		return
+	}
+	if info == nil {
+		response.Status404().Text("Requested file not found")
+		return
+	}
+
+	body, err := request.LimitedBody(*request.server.config.maxUploadSize)
+	if err != nil {
+		sendUploadError(response, err)
+		return
+	}
+
+	var byteRange *ByteRange
+
+	if contentRange := request.GetHeader("Content-Range"); contentRange != "" {
+		parsedRange, err := parseContentRange(contentRange)
+		if err != nil {
+			response.Status400().Text(fmt.Sprintf("Invalid Content-Range: %v", err))
+			return
+		}
+		if parsedRange.start > info.Size() {
+			sendRangeNotSatisfiable(response, info.Size())
+			return
+		}
+		if length := request.GetHeader("Content-Length"); length != "" && length != strconv.FormatInt(parsedRange.length(), 10) {
+			response.Status400().Text("Length of body doesn't match Content-Range")
+			return
+		}
+		byteRange = &parsedRange
+		body = &exactLengthReader{reader: body, remaining: parsedRange.length()}
+	}
+
+	tempPath, _, err := receiveUploadedFile(filepath.Dir(fullPath), body)
+	if errors.Is(err, errContentRangeMismatch) {
+		response.Status400().Text("Length of body doesn't match Content-Range")
+		return
+	}
+	if err != nil {
+		sendUploadError(response, err)
+		return
+	}
+	defer os.Remove(tempPath)
+
+	unlock := request.server.fileLocks.lock(fullPath)
+	defer unlock()
+
+	if info, ok = checkFilePreconditions(request, response, fullPath); !ok {
+		return
+	}
+	if info == nil {
+		response.Status404().Text("Requested file not found")
+		return
+	}
+
+	if byteRange == nil {
+		err = appendFile(fullPath, tempPath, info.Size())
+	} else if byteRange.start > info.Size() {
+		sendRangeNotSatisfiable(response, info.Size())
+		return
+	} else {
+		err = replaceFileRange(fullPath, tempPath, *byteRange, info.Size())
+	}
+	if err != nil {
+		log.Printf("Failed to update file: %v", err)
+		response.Status500().Text("Failed to update file")
+		return
+	}
+
+	setFileETag(request, response, fullPath)
+	response.Status204().Send()
+}
+
+func sendRangeNotSatisfiable(response *HttpResponse, size int64) {
+	response.SetHeader("Content-Range", fmt.Sprintf("bytes */%d", size))
+	response.Status(416, "Range Not Satisfiable").Send()
+}
+
+// appendFile appends content of file at tempPath to the file of given size.
+// Only appended bytes are written, and the file is truncated back to its size
+// when appending fails, so it never keeps a part of the content.
+func appendFile(fullPath string, tempPath string, size int64) error {
+	file, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	appended, err := os.Open(tempPath)
+	if err != nil {
+		return err
+	}
+	defer appended.Close()
+
+	_, err = io.Copy(file, appended)
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		file.Truncate(size)
+	}
+	return err
+}
+
+// replaceFileRange writes copy of the file of given size, with bytes of
+// byteRange replaced by content of file at tempPath, and moves it in place of
+// the file.
+func replaceFileRange(fullPath string, tempPath string, byteRange ByteRange, size int64) error {
+	original, err := os.Open(fullPath)
+	if err != nil {
+		return err
+	}
+	defer original.Close()
+
+	replacement, err := os.Open(tempPath)
+	if err != nil {
+		return err
+	}
+	defer replacement.Close()
+
+	rangeEnd := min(byteRange.end+1, size)
+	patched := io.MultiReader(
+		io.NewSectionReader(original, 0, byteRange.start),
+		replacement,
+		io.NewSectionReader(original, rangeEnd, size-rangeEnd),
+	)
+
+	_, err = saveUploadedFile(fullPath, patched, true)
+	return err
+}
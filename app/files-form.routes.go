@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type StoredFormFile struct {
+	Field string `json:"field"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+}
+
+// postFormFilesRoute stores files of multipart/form-data body under their
+// file names, in directory given by the path (files directory itself for
+// "/files/"). Parts are stored as they arrive, so files preceding a failed
+// one are kept. Responds with JSON summary of stored files.
+func postFormFilesRoute(request *HttpRequest, response *HttpResponse) {
+	directoryNames := requestDirectoryNames(request)
+	directoryPath := strings.Join(directoryNames, "/")
+	directory := *request.server.config.filesDirectory
+
+	if len(directoryNames) > 0 {
+		var ok bool
+		if directory, ok = resolveRequestFilePath(request, response, directoryNames); !ok {
+			return
+		}
+		if info, err := os.Stat(directory); err != nil || !info.IsDir() {
+			response.Status409().Text(fmt.Sprintf("Directory '%s' doesn't exist", directoryPath))
+			return
+		}
+	}
+
+	stored := []StoredFormFile{}
+	err := request.ParseMultipartForm(*request.server.config.maxUploadSize, func(part *multipart.Part) error {
+		fileName := part.FileName()
+		if err := validateFileName(fileName); err != nil {
+			return fmt.Errorf("invalid file name '%s': %w", fileName, err)
+		}
+
+		size, err := saveUploadedFile(filepath.Join(directory, fileName), part, false)
+		if err != nil {
+			return &formFileError{fileName, err}
+		}
+
+		stored = append(stored, StoredFormFile{
+			Field: part.FormName(),
+			Name:  fileName,
+			Path:  path.Join("/files", escapeFilePath(path.Join(directoryPath, fileName))),
+			Size:  size,
+		})
+		return nil
+	})
+
+	var fileErr *formFileError
+	switch {
+	case isTimeoutError(err):
+		response.RequestBodyError(err)
+		return
+	case errors.As(err, &fileErr) && errors.Is(err, os.ErrExist):
+		response.Status409().Text(fmt.Sprintf("File '%s' already exists", fileErr.name))
+		return
+	case errors.As(err, &fileErr):
+		log.Printf("Failed to save file: %v", err)
+		response.Status500().Text("Failed to save file")
+		return
+	case err != nil:
+		response.RequestBodyError(err)
+		return
+	case len(stored) == 0:
+		response.Status400().Text("Form doesn't contain any file")
+		return
+	}
+
+	response.Status(201, "Created").JSON(map[string][]StoredFormFile{"files": stored})
+}
+
+// formFileError tells which file of the form couldn't be saved
+type formFileError struct {
+	name string
+	err  error
+}
+
+func (fileErr *formFileError) Error() string {
+	return fmt.Sprintf("file '%s': %v", fileErr.name, fileErr.err)
+}
+
+func (fileErr *formFileError) Unwrap() error {
+	return fileErr.err
+}
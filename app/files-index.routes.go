@@ -0,0 +1,274 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultIndexPageSize = 100
+	maxIndexPageSize     = 1000
+)
+
+type FileIndexEntry struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	// Type is either "file" or "directory"
+	Type string `json:"type"`
+}
+
+type FileIndexPage struct {
+	Path    string           `json:"path"`
+	Sort    string           `json:"sort"`
+	Order   string           `json:"order"`
+	Page    int              `json:"page"`
+	PerPage int              `json:"per_page"`
+	Total   int              `json:"total"`
+	Entries []FileIndexEntry `json:"entries"`
+}
+
+// Media types of listing in order of preference, so that clients accepting
+// anything (like curl) get plain text
+var fileIndexMediaTypes = []string{"text/plain", "text/html", "application/json"}
+
+var fileIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{.Path}}</title></head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Modified</th><th>Type</th></tr>
+{{range .Entries}}<tr><td><a href="{{$.Link .}}">{{.Name}}</a></td><td>{{.Size}}</td><td>{{.Modified.Format "2006-01-02 15:04:05"}}</td><td>{{.Type}}</td></tr>
+{{end}}</table>
+<p>{{if .HasPrevious}}<a href="?{{.PageQuery .PreviousPage}}">Previous</a> {{end}}Page {{.Page}} of {{.PageCount}}{{if .HasNext}} <a href="?{{.PageQuery .NextPage}}">Next</a>{{end}}</p>
+</body>
+</html>
+`))
+
+func (page FileIndexPage) Link(entry FileIndexEntry) string {
+	link := page.Path + url.PathEscape(entry.Name)
+	if entry.Type == "directory" {
+		link += "/"
+	}
+	return link
+}
+
+func (page FileIndexPage) PageCount() int {
+	return max(1, (page.Total+page.PerPage-1)/page.PerPage)
+}
+
+func (page FileIndexPage) HasPrevious() bool {
+	return page.Page > 1
+}
+
+func (page FileIndexPage) HasNext() bool {
+	return page.Page < page.PageCount()
+}
+
+func (page FileIndexPage) PreviousPage() int {
+	return page.Page - 1
+}
+
+func (page FileIndexPage) NextPage() int {
+	return page.Page + 1
+}
+
+func (page FileIndexPage) PageQuery(number int) template.URL {
+	query := url.Values{}
+	query.Set("sort", page.Sort)
+	query.Set("order", page.Order)
+	query.Set("page", strconv.Itoa(number))
+	query.Set("per_page", strconv.Itoa(page.PerPage))
+	return template.URL(query.Encode())
+}
+
+// getFilesIndexRoute lists files directory or its subdirectory. Listing is rendered as HTML, JSON
+// or plain text according to Accept header, and can be sorted and paginated
+// with "sort" (name, size, mtime), "order" (asc, desc), "page" and "per_page"
+// query parameters.
+func getFilesIndexRoute(request *HttpRequest, response *HttpResponse) {
+	if !*request.server.config.directoryIndex {
+		response.Status404().Text("Name of file is not passed in URL")
+		return
+	}
+
+	response.AddVary("Accept")
+	mediaType, ok := negotiateMediaType(request.headers.GetAcceptedMediaTypes(), fileIndexMediaTypes)
+	if !ok {
+		response.Status406().Text("Listing is available as text/plain, text/html or application/json")
+		return
+	}
+
+	page, err := newFileIndexPage(request)
+	if err != nil {
+		response.Status400().Text(err.Error())
+		return
+	}
+
+	directory := *request.server.config.filesDirectory
+	if directoryNames := requestDirectoryNames(request); len(directoryNames) > 0 {
+		directoryPath := strings.Join(directoryNames, "/")
+		if err := validateFilePath(directoryNames); err != nil {
+			response.Status400().Text(fmt.Sprintf("Invalid directory name: %v", err))
+			return
+		}
+		directory, err = resolveFilePath(directory, directoryPath)
+		if errors.Is(err, errPathOutsideRoot) {
+			response.Status403().Text("Access to requested directory is forbidden")
+			return
+		}
+		if info, statErr := os.Stat(directory); err != nil || statErr != nil || !info.IsDir() {
+			response.Status404().Text("Requested directory not found")
+			return
+		}
+		page.Path += escapeFilePath(directoryPath) + "/"
+	}
+
+	entries, err := readFileIndexEntries(directory)
+	if err != nil {
+		log.Print(err)
+		response.Status500().Text("File server feature is not available!")
+		return
+	}
+
+	sortFileIndexEntries(entries, page.Sort, page.Order == "desc")
+	page.Total = len(entries)
+	start := min((page.Page-1)*page.PerPage, len(entries))
+	end := min(start+page.PerPage, len(entries))
+	page.Entries = entries[start:end]
+
+	switch mediaType {
+	case "text/html":
+		var builder strings.Builder
+		if err := fileIndexTemplate.Execute(&builder, page); err != nil {
+			log.Panicf("Couldn't render files index: %s", err)
+		}
+		response.Status200().Body(&HttpTextBody{text: builder.String(), contentType: "text/html; charset=utf-8"}).Send()
+	case "application/json":
+		response.Status200().JSON(page)
+	default:
+		var builder strings.Builder
+		for _, entry := range page.Entries {
+			fmt.Fprintf(&builder, "%s\t%d\t%s\t%s\n",
+				entry.Name, entry.Size, entry.Modified.UTC().Format(time.RFC3339), entry.Type)
+		}
+		response.Status200().Text(builder.String())
+	}
+}
+
+func newFileIndexPage(request *HttpRequest) (FileIndexPage, error) {
+	page := FileIndexPage{
+		Path:    "/files/",
+		Sort:    "name",
+		Order:   "asc",
+		Page:    1,
+		PerPage: defaultIndexPageSize,
+	}
+
+	if sortBy := request.Query("sort"); sortBy != "" {
+		if sortBy != "name" && sortBy != "size" && sortBy != "mtime" {
+			return page, fmt.Errorf("Invalid sort '%s', expected name, size or mtime", sortBy)
+		}
+		page.Sort = sortBy
+	}
+
+	if order := request.Query("order"); order != "" {
+		if order != "asc" && order != "desc" {
+			return page, fmt.Errorf("Invalid order '%s', expected asc or desc", order)
+		}
+		page.Order = order
+	}
+
+	var err error
+	if page.Page, err = parsePositiveQuery(request, "page", page.Page, 0); err != nil {
+		return page, err
+	}
+	if page.PerPage, err = parsePositiveQuery(request, "per_page", page.PerPage, maxIndexPageSize); err != nil {
+		return page, err
+	}
+
+	return page, nil
+}
+
+// parsePositiveQuery reads positive integer query parameter, limited by
+// maxValue unless it is zero.
+func parsePositiveQuery(request *HttpRequest, name string, defaultValue int, maxValue int) (int, error) {
+	rawValue := request.Query(name)
+	if rawValue == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(rawValue)
+	if err != nil || value < 1 || (maxValue > 0 && value > maxValue) {
+		return 0, fmt.Errorf("Invalid %s '%s'", name, rawValue)
+	}
+
+	return value, nil
+}
+
+func readFileIndexEntries(directory string) ([]FileIndexEntry, error) {
+	dirEntries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]FileIndexEntry, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		// Dotfiles are hidden, the same way validateFileName refuses them
+		if strings.HasPrefix(dirEntry.Name(), ".") {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			// File was removed after directory has been read
+			continue
+		}
+
+		entryType := "file"
+		if info.IsDir() {
+			entryType = "directory"
+		} else if !info.Mode().IsRegular() {
+			continue
+		}
+
+		entries = append(entries, FileIndexEntry{
+			Name:     dirEntry.Name(),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+			Type:     entryType,
+		})
+	}
+
+	return entries, nil
+}
+
+func sortFileIndexEntries(entries []FileIndexEntry, sortBy string, descending bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		first, second := entries[i], entries[j]
+		if descending {
+			first, second = second, first
+		}
+
+		switch sortBy {
+		case "size":
+			if first.Size != second.Size {
+				return first.Size < second.Size
+			}
+		case "mtime":
+			if !first.Modified.Equal(second.Modified) {
+				return first.Modified.Before(second.Modified)
+			}
+		}
+		return first.Name < second.Name
+	})
+}
@@ -3,22 +3,34 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"syscall"
 )
 
-func isFileExists(fileName string, filesDirectory string) bool {
-	fullPath := path.Join(filesDirectory, fileName)
-	_, err := os.Stat(fullPath)
+// errPathOutsideRoot is returned when path resolves (via symlinks) to a
+// location outside of files directory
+var errPathOutsideRoot = errors.New("path leads outside of files directory")
+
+func isFileExists(fullPath string) bool {
+	// Dangling symlink exists too, it would prevent creating a file anyway
+	_, err := os.Lstat(fullPath)
 	return !errors.Is(err, os.ErrNotExist)
 }
 
 func validateFileName(fileName string) error {
-	// Check for path traversal attempts
+	// NUL byte truncates file names in system calls
+	if strings.ContainsRune(fileName, 0) {
+		return fmt.Errorf("file name contains NUL character")
+	}
+
+	// Check for path traversal attempts, including encoded slashes
 	if strings.Contains(fileName, "/") || strings.Contains(fileName, "\\") {
 		return fmt.Errorf("file name contains invalid path separators")
 	}
@@ -37,77 +49,323 @@ func validateFileName(fileName string) error {
 	return nil
 }
 
+// validateFilePath checks decoded elements of path relative to files
+// directory. Every element has to be a valid file name, so the path can't
+// contain "..", empty elements, dotfiles or encoded slashes.
+func validateFilePath(names []string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("path is empty")
+	}
+
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("path contains empty element")
+		}
+		if err := validateFileName(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveFilePath joins valid relative path with files directory. Symlinks of
+// the deepest existing part of the path are evaluated, so that a link can't
+// point outside of the directory; missing part of the path is returned as is.
+func resolveFilePath(filesDirectory string, filePath string) (string, error) {
+	root, err := filepath.EvalSymlinks(filesDirectory)
+	if err != nil {
+		return "", err
+	}
+
+	missing := []string{}
+	existing := filepath.Join(root, filepath.FromSlash(filePath))
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			existing = resolved
+			break
+		}
+		// Path may continue past a regular file, which is missing as well
+		notExist := errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR)
+		if !notExist || existing == root {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(existing)}, missing...)
+		existing = filepath.Dir(existing)
+	}
+
+	relative, err := filepath.Rel(root, existing)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", errPathOutsideRoot
+	}
+
+	return filepath.Join(append([]string{existing}, missing...)...), nil
+}
+
+// makeDirectories creates missing directories of path inside of files
+// directory one by one, refusing to follow symlinks created in the meantime.
+func makeDirectories(filesDirectory string, directoryPath string) error {
+	current := filesDirectory
+	for _, name := range strings.Split(directoryPath, "/") {
+		current = filepath.Join(current, name)
+		err := os.Mkdir(current, 0755)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		info, err := os.Lstat(current)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("'%s' is not a directory", name)
+		}
+	}
+	return nil
+}
+
+// getFileRoute sends file from files directory or its subdirectories. Path
+// ending with slash refers to directory, which is listed if directory index
+// is enabled.
 func getFileRoute(request *HttpRequest, response *HttpResponse) {
-	fileName, _ := strings.CutPrefix(request.path, "/files/")
+	filePath := request.PathParam("path")
 
-	if fileName == "" {
-		response.Status404().Text("Name of file is not passed in URL")
+	if filePath == "" || strings.HasSuffix(filePath, "/") {
+		getFilesIndexRoute(request, response)
 		return
 	}
 
-	if err := validateFileName(fileName); err != nil {
+	if err := validateFilePath(request.PathParamSegments("path")); err != nil {
 		response.Status400().Text(fmt.Sprintf("Invalid file name: %v", err))
 		return
 	}
 
 	filesDirectory := *request.server.config.filesDirectory
-	files, err := os.ReadDir(filesDirectory)
+	fullFilePath, err := resolveFilePath(filesDirectory, filePath)
+	if errors.Is(err, errPathOutsideRoot) {
+		log.Printf("Requested file '%s' leads outside of folder '%s'", filePath, filesDirectory)
+		response.Status403().Text("Access to requested file is forbidden")
+		return
+	}
 	if err != nil {
 		log.Print(err)
 		response.Status500().Text("File server feature is not available!")
 		return
 	}
 
-	var targetFile fs.DirEntry
-	for _, file := range files {
-		if file.Name() == fileName && !file.IsDir() {
-			targetFile = file
-			break
-		}
-	}
-
-	if targetFile == nil {
-		log.Printf("Requested file '%s' doesn't exists in folder '$s'", fileName, filesDirectory)
+	info, err := os.Stat(fullFilePath)
+	if err != nil {
+		log.Printf("Requested file '%s' doesn't exists in folder '%s'", filePath, filesDirectory)
 		response.Status404().Text("Requested file not found")
 		return
 	}
 
-	fullFilePath := path.Join(filesDirectory, fileName)
+	if info.IsDir() {
+		if !*request.server.config.directoryIndex {
+			response.Status404().Text("Requested file not found")
+			return
+		}
+		response.SetHeader("Location", "/files/"+escapeFilePath(filePath)+"/")
+		response.Status(301, "Moved Permanently").Send()
+		return
+	}
 
-	response.SetHeader("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileName))
+	response.SetHeader("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", path.Base(filePath)))
 	response.Status200().LocalFile(fullFilePath)
 }
 
+// postFileRoute saves request body as a new file. Missing directories of the
+// path are created when "parents" query parameter is "true". Forms with files
+// are handled by postFormFilesRoute.
 func postFileRoute(request *HttpRequest, response *HttpResponse) {
-	fileName, _ := strings.CutPrefix(request.path, "/files/")
-
-	if err := validateFileName(fileName); err != nil {
-		response.Status400().Text(fmt.Sprintf("Invalid file name: %v", err))
+	if request.IsMultipartForm() {
+		postFormFilesRoute(request, response)
 		return
 	}
 
-	filesDirectory := *request.server.config.filesDirectory
-	fullPath := path.Join(filesDirectory, fileName)
-
-	// Verify the joined path is still within target directory
-	if !strings.HasPrefix(fullPath, filesDirectory) {
-		response.Status400().Text("Invalid file path")
+	filePath := request.PathParam("path")
+	fullPath, ok := resolveRequestFilePath(request, response, request.PathParamSegments("path"))
+	if !ok {
 		return
 	}
 
-	if isFileExists(fileName, filesDirectory) {
-		log.Printf("Conflict: file %s already exists.", fileName)
+	if isFileExists(fullPath) {
+		log.Printf("Conflict: file %s already exists.", filePath)
 		response.Status409().Send()
 		return
 	}
 
-	// Write content to file
-	err := os.WriteFile(fullPath, []byte(request.body), 0644)
+	body, err := request.LimitedBody(*request.server.config.maxUploadSize)
 	if err != nil {
-		log.Printf("Failed to write file: %v", err)
-		response.Status500().Text("Failed to save file")
+		sendUploadError(response, err)
+		return
+	}
+
+	if fullPath, ok = prepareFileDirectory(request, response, filePath, fullPath); !ok {
+		return
+	}
+
+	if _, err := saveUploadedFile(fullPath, body, false); err != nil {
+		sendUploadError(response, err)
 		return
 	}
 
 	response.Status(201, "Created").Send()
 }
+
+// saveUploadedFile streams body to a temporary file in the directory of the
+// target one, and moves it into place only once it is completely written and
+// synced to disk. Existing file is replaced only when replace is set,
+// otherwise error matching fs.ErrExist is returned. Size of saved file is
+// returned.
+func saveUploadedFile(fullPath string, body io.Reader, replace bool) (int64, error) {
+	tempPath, size, err := receiveUploadedFile(filepath.Dir(fullPath), body)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tempPath)
+
+	if err := commitUploadedFile(tempPath, fullPath, replace); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// receiveUploadedFile streams body to a temporary file in directory and syncs
+// it to disk. Caller is responsible for removing the file.
+func receiveUploadedFile(directory string, body io.Reader) (tempPath string, size int64, err error) {
+	// Temporary file is a dotfile, so it is neither listed nor served
+	temp, err := os.CreateTemp(directory, ".upload-*")
+	if err != nil {
+		return "", 0, err
+	}
+	tempPath = temp.Name()
+
+	size, err = io.Copy(temp, body)
+	if err == nil {
+		err = temp.Chmod(0644)
+	}
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return "", 0, err
+	}
+
+	return tempPath, size, nil
+}
+
+// commitUploadedFile moves received temporary file to fullPath, see
+// saveUploadedFile.
+func commitUploadedFile(tempPath string, fullPath string, replace bool) error {
+	var err error
+	if replace {
+		err = os.Rename(tempPath, fullPath)
+	} else {
+		// Unlike rename, link fails when target exists
+		err = os.Link(tempPath, fullPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Entry of the file becomes durable once directory is synced
+	if dir, err := os.Open(filepath.Dir(fullPath)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// sendUploadError responds to upload which couldn't be saved.
+func sendUploadError(response *HttpResponse, err error) {
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		response.Status413().Text("Uploaded file is too large")
+	case isTimeoutError(err):
+		response.Status408().Text("Uploaded file wasn't received in time")
+	case errors.Is(err, fs.ErrExist):
+		response.Status409().Send()
+	default:
+		log.Printf("Failed to save file: %v", err)
+		response.Status500().Text("Failed to save file")
+	}
+}
+
+// resolveRequestFilePath validates decoded path elements of the file targeted
+// by request and resolves it inside of files directory. When path is not
+// acceptable, error response is sent and ok is false.
+func resolveRequestFilePath(request *HttpRequest, response *HttpResponse, names []string) (fullPath string, ok bool) {
+	if err := validateFilePath(names); err != nil {
+		response.Status400().Text(fmt.Sprintf("Invalid file name: %v", err))
+		return "", false
+	}
+
+	fullPath, err := resolveFilePath(*request.server.config.filesDirectory, strings.Join(names, "/"))
+	if errors.Is(err, errPathOutsideRoot) {
+		response.Status403().Text("Access to requested path is forbidden")
+		return "", false
+	}
+	if err != nil {
+		log.Printf("Failed to resolve path: %v", err)
+		response.Status500().Text("File server feature is not available!")
+		return "", false
+	}
+
+	return fullPath, true
+}
+
+// prepareFileDirectory makes sure that directory of the file to be written
+// exists, creating missing directories when "parents" query parameter is
+// "true". Path of the file is resolved again afterwards, as directories might
+// have been replaced in the meantime.
+func prepareFileDirectory(request *HttpRequest, response *HttpResponse, filePath string, fullPath string) (string, bool) {
+	filesDirectory := *request.server.config.filesDirectory
+
+	if directoryPath := path.Dir(filePath); directoryPath != "." && request.Query("parents") == "true" {
+		if err := makeDirectories(filesDirectory, directoryPath); err != nil {
+			log.Printf("Failed to create directories: %v", err)
+			response.Status409().Text("Directories of the path can't be created")
+			return "", false
+		}
+
+		var err error
+		if fullPath, err = resolveFilePath(filesDirectory, filePath); err != nil {
+			response.Status403().Text("Access to requested path is forbidden")
+			return "", false
+		}
+	}
+
+	if info, err := os.Stat(filepath.Dir(fullPath)); err != nil || !info.IsDir() {
+		response.Status409().Text(fmt.Sprintf("Directory '%s' doesn't exist", path.Dir(filePath)))
+		return "", false
+	}
+
+	return fullPath, true
+}
+
+// requestDirectoryNames returns decoded path elements of the directory
+// targeted by request, for which trailing slash is optional.
+func requestDirectoryNames(request *HttpRequest) []string {
+	names := request.PathParamSegments("path")
+	if len(names) > 0 && names[len(names)-1] == "" {
+		names = names[:len(names)-1]
+	}
+	return names
+}
+
+// escapeFilePath percent-encodes elements of slash separated path.
+func escapeFilePath(filePath string) string {
+	names := strings.Split(filePath, "/")
+	for index, name := range names {
+		names[index] = url.PathEscape(name)
+	}
+	return strings.Join(names, "/")
+}
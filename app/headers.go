@@ -2,36 +2,155 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
-type HttpHeaders map[string]string
+type HttpHeaderField struct {
+	// name is kept in the casing it was given
+	name  string
+	value string
+}
+
+// HttpHeaders is an ordered collection of header fields. The same header may
+// occur several times (like Set-Cookie), names are compared case-insensitively
+// and fields are emitted in order they were added. Zero value is ready to use.
+type HttpHeaders struct {
+	fields []HttpHeaderField
+}
+
+// Add appends a field, keeping fields with the same name.
+func (headers *HttpHeaders) Add(name string, value string) {
+	headers.fields = append(headers.fields, HttpHeaderField{
+		name:  strings.TrimSpace(name),
+		value: sanitizeHeaderValue(value),
+	})
+}
+
+// Set replaces all fields with given name by a single one. The field keeps
+// position of the first replaced one.
+func (headers *HttpHeaders) Set(name string, value string) {
+	name = strings.TrimSpace(name)
+	fields := headers.fields[:0]
+	replaced := false
 
-type HttpResponseHeaders HttpHeaders
+	for _, field := range headers.fields {
+		if !strings.EqualFold(field.name, name) {
+			fields = append(fields, field)
+		} else if !replaced {
+			fields = append(fields, HttpHeaderField{name: name, value: sanitizeHeaderValue(value)})
+			replaced = true
+		}
+	}
+
+	headers.fields = fields
+	if !replaced {
+		headers.Add(name, value)
+	}
+}
+
+// Get returns value of the first field with given name.
+func (headers *HttpHeaders) Get(name string) string {
+	for _, field := range headers.fields {
+		if strings.EqualFold(field.name, name) {
+			return field.value
+		}
+	}
+	return ""
+}
 
-func (headers HttpResponseHeaders) String() string {
-	if len(headers) == 0 {
-		return ""
+// Values returns values of all fields with given name in order of appearance.
+func (headers *HttpHeaders) Values(name string) []string {
+	values := []string{}
+	for _, field := range headers.fields {
+		if strings.EqualFold(field.name, name) {
+			values = append(values, field.value)
+		}
 	}
+	return values
+}
+
+// GetList returns values of list-based header (like Accept-Encoding), which
+// may be split among several fields, combined into a single comma separated
+// value.
+func (headers *HttpHeaders) GetList(name string) string {
+	return strings.Join(headers.Values(name), ", ")
+}
 
-	headersStrArray := make([]string, 0, len(headers))
-	for headerName, headerValue := range headers {
-		headersStrArray = append(headersStrArray, fmt.Sprintf("%s: %s", headerName, headerValue))
+func (headers *HttpHeaders) Has(name string) bool {
+	return len(headers.Values(name)) > 0
+}
+
+func (headers *HttpHeaders) Del(name string) {
+	fields := headers.fields[:0]
+	for _, field := range headers.fields {
+		if !strings.EqualFold(field.name, name) {
+			fields = append(fields, field)
+		}
 	}
+	headers.fields = fields
+}
 
-	return strings.Join(headersStrArray[:], "\r\n") + "\r\n"
+// String formats fields as they are sent over the wire, each line is
+// terminated by CRLF.
+func (headers *HttpHeaders) String() string {
+	var builder strings.Builder
+	for _, field := range headers.fields {
+		fmt.Fprintf(&builder, "%s: %s\r\n", canonicalHeaderName(field.name), field.value)
+	}
+	return builder.String()
 }
 
-type HttpRequestHeaders HttpHeaders
+// Header names which canonical form doesn't follow the usual capitalization
+var canonicalHeaderExceptions = map[string]string{
+	"etag":             "ETag",
+	"te":               "TE",
+	"www-authenticate": "WWW-Authenticate",
+	"content-md5":      "Content-MD5",
+	"dnt":              "DNT",
+}
 
-func (headers *HttpRequestHeaders) Get(key string) string {
-	value, ok := (*headers)[strings.ToLower(key)]
+// canonicalHeaderName capitalizes the first letter of each dash separated
+// word of the name, e.g. "content-type" becomes "Content-Type".
+func canonicalHeaderName(name string) string {
+	lowerName := strings.ToLower(name)
+	if exception, ok := canonicalHeaderExceptions[lowerName]; ok {
+		return exception
+	}
 
-	if ok {
-		return value
+	words := strings.Split(lowerName, "-")
+	for index, word := range words {
+		if word != "" {
+			words[index] = strings.ToUpper(word[:1]) + word[1:]
+		}
 	}
+	return strings.Join(words, "-")
+}
+
+// sanitizeHeaderValue trims value and removes line breaks, which would let
+// the value inject additional header fields into the response.
+func sanitizeHeaderValue(value string) string {
+	value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+	return strings.Trim(value, " \t")
+}
 
-	return (*headers)[key]
+type HttpResponseHeaders struct {
+	HttpHeaders
+}
+
+// headerHasToken checks whether comma separated header value (like the one of
+// Connection header) contains given token, ignoring case.
+func headerHasToken(value string, token string) bool {
+	for _, part := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+	return false
+}
+
+type HttpRequestHeaders struct {
+	HttpHeaders
 }
 
 type AcepptedEcoding struct {
@@ -40,31 +159,103 @@ type AcepptedEcoding struct {
 }
 
 func (headers HttpRequestHeaders) GetAceeptedEncodings() []AcepptedEcoding {
-	accpetedEncoding := headers.Get("Accept-Encoding")
-	// log.Printf("Accepted encoding: %w", headers)
+	values := parseQualityList(headers.GetList("Accept-Encoding"))
+	encodings := make([]AcepptedEcoding, 0, len(values))
+	for _, value := range values {
+		encodings = append(encodings, AcepptedEcoding{value.value, value.quality})
+	}
+	return encodings
+}
+
+// GetAcceptedMediaTypes returns media types from Accept header with their
+// quality values.
+func (headers HttpRequestHeaders) GetAcceptedMediaTypes() []QualityValue {
+	return parseQualityList(headers.GetList("Accept"))
+}
 
-	if accpetedEncoding == "" {
-		return []AcepptedEcoding{}
+// QualityValue is an item of list with weights, like the ones of Accept and
+// Accept-Encoding headers.
+type QualityValue struct {
+	value   string
+	quality float64
+}
+
+// parseQualityList parses list like "gzip;q=0.5, br". Items are lowercased,
+// parameters other than quality are dropped.
+func parseQualityList(list string) []QualityValue {
+	if list == "" {
+		return []QualityValue{}
 	}
 
-	encodingsData := strings.Split(accpetedEncoding, ",")
-	encodings := make([]AcepptedEcoding, 0, len(encodingsData))
-	for _, encoding := range encodingsData {
-		encoding = strings.TrimSpace(encoding)
-		if encoding == "" {
+	itemsData := strings.Split(list, ",")
+	items := make([]QualityValue, 0, len(itemsData))
+	for _, item := range itemsData {
+		item = strings.TrimSpace(item)
+		if item == "" {
 			continue
 		}
-		encodingParts := strings.Split(encoding, ";")
-		encodingName := strings.TrimSpace(encodingParts[0])
-		if encodingName == "" {
+		itemParts := strings.Split(item, ";")
+		itemValue := strings.ToLower(strings.TrimSpace(itemParts[0]))
+		if itemValue == "" {
 			continue
 		}
-		encodingQuality := 1.0
-		if len(encodingParts) > 1 {
-			qValue := strings.Split(encodingParts[1], "=")[1]
-			fmt.Sscanf(qValue, "%f", &encodingQuality)
+		itemQuality := 1.0
+		for _, parameter := range itemParts[1:] {
+			name, value, _ := strings.Cut(parameter, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil || quality < 0 || quality > 1 {
+					// Invalid quality can't be trusted, so item is ignored
+					quality = 0
+				}
+				itemQuality = quality
+			}
 		}
-		encodings = append(encodings, AcepptedEcoding{encodingName, encodingQuality})
+		items = append(items, QualityValue{itemValue, itemQuality})
 	}
-	return encodings
+	return items
+}
+
+// negotiateMediaType picks the offered media type with the highest quality in
+// Accept header, preferring types offered first. Without Accept header every
+// type is acceptable. When none of types is acceptable, ok is false.
+func negotiateMediaType(accepted []QualityValue, offered []string) (mediaType string, ok bool) {
+	if len(accepted) == 0 {
+		return offered[0], true
+	}
+
+	bestQuality := 0.0
+	for _, candidate := range offered {
+		quality := mediaTypeQuality(accepted, candidate)
+		if quality > bestQuality {
+			mediaType = candidate
+			bestQuality = quality
+		}
+	}
+
+	return mediaType, mediaType != ""
+}
+
+// mediaTypeQuality returns quality of the most specific range from Accept
+// header which matches media type (RFC 9110, section 12.5.1).
+func mediaTypeQuality(accepted []QualityValue, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+
+	for _, mediaRange := range accepted {
+		rangeSpecificity := -1
+		switch mediaRange.value {
+		case mediaType:
+			rangeSpecificity = 2
+		case mainType + "/*":
+			rangeSpecificity = 1
+		case "*/*":
+			rangeSpecificity = 0
+		}
+		if rangeSpecificity > specificity {
+			quality, specificity = mediaRange.quality, rangeSpecificity
+		}
+	}
+
+	return quality
 }
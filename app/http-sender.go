@@ -12,29 +12,34 @@ type HttpSender struct {
 }
 
 func (sender *HttpSender) SendAll(response *HttpResponse) {
-	var bodyToSend IHttpBody
+	if response.sent {
+		log.Panic("Response has been sent already")
+	}
+	response.sent = true
 
 	if response.body == nil {
-		bodyToSend = &HttpTextBody{
+		response.body = &HttpTextBody{
 			text: "",
 		}
-	} else {
-		bodyToSend = response.body
 	}
 
-	if response.request.AceeptsEncoding("gzip") {
-		log.Println("Compressing body...")
-		response.SetHeader("Content-Encoding", "gzip")
-		bodyToSend = NewCompressedBody(bodyToSend)
+	// Hooks may still change status, headers and body
+	for _, hook := range response.beforeSend {
+		hook(response)
 	}
 
+	bodyToSend := response.body
+
 	log.Println("Sending response...")
 	statusStr := sender.sendStatus(response)
 	fmt.Print(*statusStr)
 	headersStr := sender.sendHeaders(response, &bodyToSend)
 	fmt.Print(*headersStr)
-	bodyStr := sender.sendBody(bodyToSend)
-	fmt.Println(*bodyStr)
+	// Response to HEAD has the same headers as to GET, but never a body
+	if !response.request.IsHead() && !response.hasNoBody() {
+		bodyStr := sender.sendBody(response, bodyToSend)
+		fmt.Println(*bodyStr)
+	}
 	log.Println("Response sent.")
 }
 
@@ -51,11 +56,43 @@ func (sender *HttpSender) sendStatus(response *HttpResponse) *string {
 }
 
 func (sender *HttpSender) sendHeaders(response *HttpResponse, bodyToSend *IHttpBody) *string {
-	response.SetHeader("Content-Type", (*bodyToSend).ContentType())
+	if response.hasNoBody() {
+		return sender.writeHeaders(response)
+	}
 
-	// Use the body that will actually be sent (might be compressed)
-	if sizedBody, ok := (*bodyToSend).(IHttpBodyDefinedLength); ok {
+	// Type set by handler takes precedence over the one of body
+	if !response.headers.Has("Content-Type") {
+		response.SetHeader("Content-Type", (*bodyToSend).ContentType())
+	}
+
+	sizedBody, hasLength := (*bodyToSend).(IHttpBodyDefinedLength)
+	// Trailers can be delivered only after the last chunk
+	hasTrailers := response.GetHeaders().Has("Trailer")
+	supportsChunked := response.request.protocol != "HTTP/1.0"
+
+	if hasLength && !(hasTrailers && supportsChunked) {
+		// Use the body that will actually be sent (might be compressed)
 		response.SetHeader("Content-Length", fmt.Sprintf("%d", sizedBody.ContentLength()))
+	} else if response.request.IsHead() {
+		// No body follows response to HEAD, so it neither needs framing nor
+		// closing of connection, and it may omit Content-Length (RFC 9110).
+	} else if supportsChunked {
+		response.SetHeader("Transfer-Encoding", "chunked")
+		response.chunked = true
+	} else {
+		// HTTP/1.0 clients don't know chunked encoding, so the only way for
+		// them to find the end of the body is connection close.
+		response.keepAlive = false
+	}
+
+	return sender.writeHeaders(response)
+}
+
+func (sender *HttpSender) writeHeaders(response *HttpResponse) *string {
+	if !response.keepAlive {
+		response.SetHeader("Connection", "close")
+	} else if response.request.protocol == "HTTP/1.0" {
+		response.SetHeader("Connection", "keep-alive")
 	}
 
 	headersStr := fmt.Sprintf("%s\r\n", response.GetHeaders().String())
@@ -72,22 +109,49 @@ func (sender *HttpSender) sendHeaders(response *HttpResponse, bodyToSend *IHttpB
 	return &headersStr
 }
 
-func (sender *HttpSender) sendBody(body interface{}) *string {
+func (sender *HttpSender) sendBody(response *HttpResponse, body interface{}) *string {
+	var writer io.Writer = sender.conn
+	var chunkedWriter *ChunkedWriter
+
+	if response.chunked {
+		chunkedWriter = NewChunkedWriter(sender.conn)
+		writer = chunkedWriter
+	}
+
+	var bodyStr *string
 	switch typedBody := body.(type) {
+	case *HttpFileBody:
+		// Chunks have to be framed in user space
+		if chunkedWriter == nil {
+			log.Println("Sending file with zero-copy...")
+			bodyStr = sender.SendFile(typedBody)
+		} else {
+			bodyStr = sender.SendBodyAsStream(writer, typedBody)
+		}
+	case io.WriterTo:
+		log.Println("Streaming body...")
+		bodyStr = sender.SendBodyFromProducer(writer, typedBody)
 	case io.Reader:
-		return sender.SendBodyAsStream(typedBody)
+		bodyStr = sender.SendBodyAsStream(writer, typedBody)
 	case fmt.Stringer:
 		log.Println("Sending body as text...")
-		return sender.SendBodyAsText(typedBody)
+		bodyStr = sender.SendBodyAsText(writer, typedBody)
 	default:
 		log.Panic("Unsupported body type")
 	}
-	return nil
+
+	if chunkedWriter != nil {
+		if err := chunkedWriter.Close(response.declaredTrailers()); err != nil {
+			log.Panic("Error sending last chunk: ", err.Error())
+		}
+	}
+
+	return bodyStr
 }
 
-func (sender *HttpSender) SendBodyAsText(body fmt.Stringer) *string {
+func (sender *HttpSender) SendBodyAsText(writer io.Writer, body fmt.Stringer) *string {
 	bodyStr := body.String()
-	_, err := sender.conn.Write([]byte(bodyStr))
+	_, err := writer.Write([]byte(bodyStr))
 
 	if err != nil {
 		log.Panic(err)
@@ -96,11 +160,43 @@ func (sender *HttpSender) SendBodyAsText(body fmt.Stringer) *string {
 	return &bodyStr
 }
 
-func (sender *HttpSender) SendBodyAsStream(body io.Reader) *string {
+func (sender *HttpSender) SendBodyFromProducer(writer io.Writer, body io.WriterTo) *string {
+	written, err := body.WriteTo(writer)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bodyStr := fmt.Sprintf("<%d bytes streamed>", written)
+
+	return &bodyStr
+}
+
+// SendFile writes content of file body straight to connection. When
+// connection is a TCP one, data is copied by kernel (with sendfile or splice)
+// without passing through user-space buffers.
+func (sender *HttpSender) SendFile(body *HttpFileBody) *string {
+	reader, err := body.fileReader()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// Connection implements io.ReaderFrom, which recognizes file behind the
+	// limited reader
+	written, err := io.Copy(sender.conn, reader)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	bodyStr := fmt.Sprintf("<%d bytes of file sent>", written)
+
+	return &bodyStr
+}
+
+func (sender *HttpSender) SendBodyAsStream(writer io.Writer, body io.Reader) *string {
 	// Create custom buffer with specific size
 	buf := make([]byte, 1024)
 
-	_, err := io.CopyBuffer(sender.conn, body, buf)
+	_, err := io.CopyBuffer(writer, body, buf)
 	if err != nil {
 		log.Panic(err)
 	}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const benchmarkFileSize = 64 * 1024 * 1024
+
+// newBenchmarkSender connects sender to TCP listener which discards all
+// received data, like a fast client would. Connection applies write timeout
+// the same way as connections of server do.
+func newBenchmarkSender(b *testing.B) *HttpSender {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatalf("Failed to listen: %v", err)
+	}
+	b.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		b.Fatalf("Failed to connect: %v", err)
+	}
+	b.Cleanup(func() { conn.Close() })
+
+	writeTimeout := time.Minute
+	server := &Server{config: ServerConfig{writeTimeout: &writeTimeout}}
+	return &HttpSender{conn: &TimeoutConn{Conn: conn, server: server}}
+}
+
+func newBenchmarkFile(b *testing.B) (string, os.FileInfo) {
+	filePath := filepath.Join(b.TempDir(), "benchmark.bin")
+	if err := os.WriteFile(filePath, make([]byte, benchmarkFileSize), 0644); err != nil {
+		b.Fatalf("Failed to create file: %v", err)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		b.Fatalf("Failed to stat file: %v", err)
+	}
+
+	return filePath, info
+}
+
+func BenchmarkSendFileZeroCopy(b *testing.B) {
+	sender := newBenchmarkSender(b)
+	filePath, info := newBenchmarkFile(b)
+	b.SetBytes(benchmarkFileSize)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		body := &HttpFileBody{path: filePath, info: info}
+		sender.SendFile(body)
+		body.Close()
+	}
+}
+
+func BenchmarkSendFileBuffered(b *testing.B) {
+	sender := newBenchmarkSender(b)
+	filePath, info := newBenchmarkFile(b)
+	b.SetBytes(benchmarkFileSize)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		body := &HttpFileBody{path: filePath, info: info}
+		// Hides ReadFrom of connection, which would bypass the buffer
+		sender.SendBodyAsStream(struct{ io.Writer }{sender.conn}, body)
+		body.Close()
+	}
+}
+
+// streamOverPipe sends streamed response to request of given protocol and
+// reads it on the other end of the connection like a client would.
+func streamOverPipe(t *testing.T, protocol string, trailer string, producer func(response *HttpResponse, writer io.Writer) error) (*http.Response, string) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	request := &HttpRequest{method: "GET", protocol: protocol}
+	response := &HttpResponse{sender: HttpSender{conn: serverConn}, request: request, keepAlive: true}
+	if trailer != "" {
+		response.SetHeader("Trailer", trailer)
+	}
+
+	go func() {
+		// Client reads body of HTTP/1.0 response until connection is closed
+		defer serverConn.Close()
+		response.Status200().Stream("text/plain", func(writer io.Writer) error {
+			return producer(response, writer)
+		})
+	}()
+
+	resp, err := http.ReadResponse(bufio.NewReader(clientConn), &http.Request{Method: "GET"})
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+	return resp, string(body)
+}
+
+func TestStreamedResponse(t *testing.T) {
+	produceParts := func(response *HttpResponse, writer io.Writer) error {
+		for _, part := range []string{"first ", "second ", "third"} {
+			if _, err := fmt.Fprint(writer, part); err != nil {
+				return err
+			}
+		}
+		response.SetTrailer("Checksum", "abc")
+		response.SetTrailer("Undeclared", "dropped")
+		return nil
+	}
+
+	t.Run("Body is sent in chunks followed by declared trailers", func(t *testing.T) {
+		resp, body := streamOverPipe(t, "HTTP/1.1", "Checksum", produceParts)
+
+		if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" {
+			t.Errorf("Expected chunked transfer encoding, got: %v", resp.TransferEncoding)
+		}
+		if body != "first second third" {
+			t.Errorf("Expected streamed body, got: '%s'", body)
+		}
+		if checksum := resp.Trailer.Get("Checksum"); checksum != "abc" {
+			t.Errorf("Expected trailer 'Checksum: abc', got: '%s'", checksum)
+		}
+		if undeclared := resp.Trailer.Get("Undeclared"); undeclared != "" {
+			t.Errorf("Expected undeclared trailer to be dropped, got: '%s'", undeclared)
+		}
+		if resp.Close {
+			t.Errorf("Expected connection to be kept alive")
+		}
+	})
+
+	t.Run("HTTP/1.0 body ends with connection close", func(t *testing.T) {
+		resp, body := streamOverPipe(t, "HTTP/1.0", "Checksum", produceParts)
+
+		if len(resp.TransferEncoding) != 0 || resp.ContentLength != -1 {
+			t.Errorf("Expected neither chunked encoding nor length, got: %v %d", resp.TransferEncoding, resp.ContentLength)
+		}
+		if !resp.Close {
+			t.Errorf("Expected 'Connection: close' header in response")
+		}
+		if body != "first second third" {
+			t.Errorf("Expected streamed body, got: '%s'", body)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// ServerMetrics counts events of server since start. Counters are updated
+// concurrently by connections, so they are atomic.
+type ServerMetrics struct {
+	connectionsAccepted atomic.Int64
+	requestsServed      atomic.Int64
+	idleTimeouts        atomic.Int64
+	headerReadTimeouts  atomic.Int64
+	bodyReadTimeouts    atomic.Int64
+	writeTimeouts       atomic.Int64
+}
+
+// String formats counters in Prometheus text format.
+func (metrics *ServerMetrics) String() string {
+	counters := []struct {
+		name  string
+		help  string
+		value int64
+	}{
+		{"http_connections_accepted_total", "Connections accepted by server.", metrics.connectionsAccepted.Load()},
+		{"http_requests_served_total", "Requests parsed and passed to router.", metrics.requestsServed.Load()},
+		{"http_idle_timeouts_total", "Keep-alive connections closed after idle timeout.", metrics.idleTimeouts.Load()},
+		{"http_header_read_timeouts_total", "Requests which headers weren't received in time.", metrics.headerReadTimeouts.Load()},
+		{"http_body_read_timeouts_total", "Requests which body stalled for longer than body timeout.", metrics.bodyReadTimeouts.Load()},
+		{"http_write_timeouts_total", "Responses which couldn't be written in time.", metrics.writeTimeouts.Load()},
+	}
+
+	var builder strings.Builder
+	for _, counter := range counters {
+		fmt.Fprintf(&builder, "# HELP %s %s\n# TYPE %s counter\n%s %d\n",
+			counter.name, counter.help, counter.name, counter.name, counter.value)
+	}
+	return builder.String()
+}
+
+func routeMetrics(request *HttpRequest, response *HttpResponse) {
+	response.Status200().Text(request.server.metrics.String())
+}
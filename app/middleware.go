@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Middleware wraps handler with cross-cutting behaviour. It may call next
+// handler, or respond on its own to short-circuit the chain. Headers can be
+// changed right before they are sent with HttpResponse.OnBeforeSend.
+type Middleware func(next HttpHandler) HttpHandler
+
+// chainMiddlewares wraps handler so that the first middleware is the
+// outermost one, i.e. it is called first and observes the final result.
+func chainMiddlewares(handler HttpHandler, middlewares []Middleware) HttpHandler {
+	for index := len(middlewares) - 1; index >= 0; index-- {
+		handler = middlewares[index](handler)
+	}
+	return handler
+}
+
+// RecoveryMiddleware turns panic of handler into 500 response. When panic
+// happens after response has been (partially) sent, the connection is closed
+// as its state is unknown.
+func RecoveryMiddleware(next HttpHandler) HttpHandler {
+	return func(request *HttpRequest, response *HttpResponse) {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Printf("Unhandled error in handler of %s %s: %v", request.method, request.path, err)
+				if response.IsSent() {
+					response.keepAlive = false
+				} else {
+					response.Status500().Text("Internal server error")
+				}
+			}
+		}()
+
+		next(request, response)
+	}
+}
+
+func LoggingMiddleware(next HttpHandler) HttpHandler {
+	return func(request *HttpRequest, response *HttpResponse) {
+		log.Printf("Received request: %s %s %s", request.method, request.path, request.protocol)
+		start := time.Now()
+
+		next(request, response)
+
+		log.Printf("%s %s -> %d (%s)", request.method, request.path, response.StatusCode(), time.Since(start))
+	}
+}
+
+// TimingMiddleware reports time spent by handler before sending response in
+// Server-Timing header.
+func TimingMiddleware(next HttpHandler) HttpHandler {
+	return func(request *HttpRequest, response *HttpResponse) {
+		start := time.Now()
+		response.OnBeforeSend(func(response *HttpResponse) {
+			duration := float64(time.Since(start).Microseconds()) / 1000
+			response.SetHeader("Server-Timing", fmt.Sprintf("app;dur=%.3f", duration))
+		})
+
+		next(request, response)
+	}
+}
+
+// CompressionMiddleware compresses response body with content coding
+// negotiated according to Accept-Encoding header and compression policy.
+func CompressionMiddleware(next HttpHandler) HttpHandler {
+	return func(request *HttpRequest, response *HttpResponse) {
+		response.OnBeforeSend(compressResponse)
+		next(request, response)
+	}
+}
+
+func compressResponse(response *HttpResponse) {
+	// Response depends on Accept-Encoding even if it ends up not compressed
+	response.AddVary("Accept-Encoding")
+
+	// Only content of successful responses is negotiated, errors and
+	// responses without body are sent as they are
+	if response.hasNoBody() || response.StatusCode()/100 != 2 {
+		return
+	}
+
+	server := response.request.server
+	accepted := response.request.headers.GetAceeptedEncodings()
+	encoder, acceptable := negotiateEncoding(accepted, server.encoders)
+	// Policy only saves effort of server, so it doesn't apply when client
+	// won't take the body as is
+	mustCompress := isIdentityRefused(accepted) && canCompress(response)
+
+	if !acceptable {
+		log.Println("None of content codings is acceptable for client")
+		response.Status406().Body(&HttpTextBody{text: "None of supported content codings is acceptable"})
+	} else if encoder != nil && (mustCompress || server.config.compressionPolicy.ShouldCompress(response, response.body)) {
+		log.Printf("Compressing body with %s...", encoder.Name())
+		response.SetHeader("Content-Encoding", encoder.Name())
+		// Compressed content differs byte by byte from the original one, so
+		// strong entity tag of the original doesn't hold anymore
+		if etag := response.headers.Get("ETag"); etag != "" && !isWeakETag(etag) {
+			response.SetHeader("ETag", "W/"+etag)
+		}
+		// Response to HEAD is compressed too, so it gets the same
+		// Content-Length as response to GET
+		response.Body(NewCompressedBody(response.body, encoder, *server.config.compressionBufferLimit))
+	}
+}
+
+// BasicAuthMiddleware lets through only requests with given credentials sent
+// using Basic authentication scheme.
+func BasicAuthMiddleware(realm string, username string, password string) Middleware {
+	return func(next HttpHandler) HttpHandler {
+		return func(request *HttpRequest, response *HttpResponse) {
+			if !hasBasicCredentials(request, username, password) {
+				response.SetHeader("WWW-Authenticate", fmt.Sprintf("Basic realm=\"%s\", charset=\"UTF-8\"", realm))
+				response.Status(401, "Unauthorized").Text("Authentication required")
+				return
+			}
+
+			next(request, response)
+		}
+	}
+}
+
+func hasBasicCredentials(request *HttpRequest, username string, password string) bool {
+	scheme, encoded, _ := strings.Cut(request.GetHeader("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Basic") {
+		return false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return false
+	}
+
+	givenUsername, givenPassword, _ := strings.Cut(string(decoded), ":")
+	// Constant time comparison doesn't reveal how many characters matched
+	usernameMatches := subtle.ConstantTimeCompare([]byte(givenUsername), []byte(username)) == 1
+	passwordMatches := subtle.ConstantTimeCompare([]byte(givenPassword), []byte(password)) == 1
+
+	return usernameMatches && passwordMatches
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Content types of extensions known out of the box
+var defaultMimeTypes = map[string]string{
+	".html":  "text/html",
+	".htm":   "text/html",
+	".css":   "text/css",
+	".js":    "text/javascript",
+	".mjs":   "text/javascript",
+	".json":  "application/json",
+	".xml":   "application/xml",
+	".txt":   "text/plain",
+	".md":    "text/markdown",
+	".csv":   "text/csv",
+	".svg":   "image/svg+xml",
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".gif":   "image/gif",
+	".webp":  "image/webp",
+	".avif":  "image/avif",
+	".ico":   "image/vnd.microsoft.icon",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".mp3":   "audio/mpeg",
+	".wav":   "audio/wav",
+	".mp4":   "video/mp4",
+	".webm":  "video/webm",
+	".pdf":   "application/pdf",
+	".zip":   "application/zip",
+	".gz":    "application/gzip",
+	".tgz":   "application/gzip",
+	".wasm":  "application/wasm",
+}
+
+// Content types which are textual even though they are not text/*
+var textualMimeTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"image/svg+xml":          true,
+}
+
+// Number of bytes inspected to sniff content type
+const sniffLength = 512
+
+// MimeTypes maps file extensions to content types.
+type MimeTypes struct {
+	types map[string]string
+}
+
+// NewMimeTypes creates table of default types, extended by types from file in
+// mime.types format (content type followed by its extensions on each line),
+// if path is not empty.
+func NewMimeTypes(path string) (*MimeTypes, error) {
+	mimeTypes := &MimeTypes{types: map[string]string{}}
+	for extension, contentType := range defaultMimeTypes {
+		mimeTypes.types[extension] = contentType
+	}
+
+	if path == "" {
+		return mimeTypes, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if !strings.Contains(fields[0], "/") {
+			return nil, fmt.Errorf("%s:%d: invalid content type '%s'", path, lineNumber, fields[0])
+		}
+		for _, extension := range fields[1:] {
+			mimeTypes.types["."+strings.ToLower(strings.TrimPrefix(extension, "."))] = fields[0]
+		}
+	}
+
+	return mimeTypes, scanner.Err()
+}
+
+// TypeByExtension returns content type of file according to its extension,
+// with charset for textual types. Empty string means that type is unknown.
+func (mimeTypes *MimeTypes) TypeByExtension(fileName string) string {
+	contentType, ok := mimeTypes.types[strings.ToLower(filepath.Ext(fileName))]
+	if !ok {
+		return ""
+	}
+	return withCharset(contentType)
+}
+
+func withCharset(contentType string) string {
+	if strings.Contains(contentType, "charset=") {
+		return contentType
+	}
+	if strings.HasPrefix(contentType, "text/") || textualMimeTypes[contentType] {
+		return contentType + "; charset=utf-8"
+	}
+	return contentType
+}
+
+// Signatures of binary formats recognized by the first bytes of content
+var contentSignatures = []struct {
+	prefix      []byte
+	contentType string
+}{
+	{[]byte("%PDF-"), "application/pdf"},
+	{[]byte("\x89PNG\r\n\x1a\n"), "image/png"},
+	{[]byte("\xff\xd8\xff"), "image/jpeg"},
+	{[]byte("GIF87a"), "image/gif"},
+	{[]byte("GIF89a"), "image/gif"},
+	{[]byte("PK\x03\x04"), "application/zip"},
+	{[]byte("\x1f\x8b\x08"), "application/gzip"},
+	{[]byte("wOFF"), "font/woff"},
+	{[]byte("wOF2"), "font/woff2"},
+	{[]byte("\x00asm"), "application/wasm"},
+}
+
+// Markup prefixes, compared case-insensitively after leading whitespace
+var markupSignatures = []struct {
+	prefix      string
+	contentType string
+}{
+	{"<!doctype html", "text/html; charset=utf-8"},
+	{"<html", "text/html; charset=utf-8"},
+	{"<head", "text/html; charset=utf-8"},
+	{"<body", "text/html; charset=utf-8"},
+	{"<?xml", "text/xml; charset=utf-8"},
+	{"<svg", "image/svg+xml; charset=utf-8"},
+}
+
+// sniffContentType guesses content type by the first bytes of content. Text
+// which isn't recognized as markup is reported as plain text, everything
+// else as generic binary data.
+func sniffContentType(content []byte) string {
+	if len(content) > sniffLength {
+		content = content[:sniffLength]
+	}
+
+	for _, signature := range contentSignatures {
+		if bytes.HasPrefix(content, signature.prefix) {
+			return signature.contentType
+		}
+	}
+	if len(content) >= 12 && bytes.Equal(content[:4], []byte("RIFF")) && bytes.Equal(content[8:12], []byte("WEBP")) {
+		return "image/webp"
+	}
+
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.ToLower(bytes.TrimLeft(content, " \t\r\n"))
+	for _, signature := range markupSignatures {
+		if bytes.HasPrefix(trimmed, []byte(signature.prefix)) {
+			return signature.contentType
+		}
+	}
+
+	if isText(content) {
+		return "text/plain; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+func isText(content []byte) bool {
+	for _, char := range content {
+		// Control characters other than whitespace don't appear in text
+		if char < 0x20 && char != '\t' && char != '\n' && char != '\r' && char != '\f' && char != 0x1b {
+			return false
+		}
+	}
+
+	// Sample may end in the middle of multibyte character
+	for trimmed := 0; trimmed < utf8.UTFMax && len(content) > 0; trimmed++ {
+		if utf8.Valid(content) {
+			return true
+		}
+		content = content[:len(content)-1]
+	}
+
+	return len(content) == 0
+}
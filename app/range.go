@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format of dates in headers like Last-Modified (RFC 9110, section 5.6.7)
+const httpDateFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+// Requests with more ranges are served in full, as such requests are more
+// likely an attempt to exhaust the server than a real need.
+const maxRangesPerRequest = 16
+
+var errRangeNotSatisfiable = errors.New("none of requested ranges is satisfiable")
+
+// ByteRange is a range of representation bytes, both ends are inclusive.
+type ByteRange struct {
+	start int64
+	end   int64
+}
+
+func (byteRange ByteRange) length() int64 {
+	return byteRange.end - byteRange.start + 1
+}
+
+func (byteRange ByteRange) contentRange(size int64) string {
+	return "bytes " + strconv.FormatInt(byteRange.start, 10) + "-" +
+		strconv.FormatInt(byteRange.end, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+func formatHttpDate(date time.Time) string {
+	return date.UTC().Format(httpDateFormat)
+}
+
+func parseHttpDate(value string) (time.Time, bool) {
+	date, err := time.Parse(httpDateFormat, strings.TrimSpace(value))
+	return date, err == nil
+}
+
+// parseRangeHeader returns ranges requested by Range header for content of
+// given size. Nil result without error means that header has to be ignored
+// and the whole content is sent, which is the case for unknown units and
+// invalid syntax. Unsatisfiable ranges are skipped, errRangeNotSatisfiable is
+// returned when none of ranges is left.
+func parseRangeHeader(header string, size int64) ([]ByteRange, error) {
+	rawRanges, isBytes := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !isBytes {
+		return nil, nil
+	}
+
+	specs := strings.Split(rawRanges, ",")
+	if len(specs) > maxRangesPerRequest {
+		return nil, nil
+	}
+
+	ranges := make([]ByteRange, 0, len(specs))
+	for _, spec := range specs {
+		rawStart, rawEnd, found := strings.Cut(strings.TrimSpace(spec), "-")
+		if !found {
+			return nil, nil
+		}
+
+		if rawStart == "" {
+			// Suffix range "-n" asks for the last n bytes
+			suffixLength, err := strconv.ParseInt(rawEnd, 10, 64)
+			if err != nil || suffixLength < 0 {
+				return nil, nil
+			}
+			if suffixLength == 0 || size == 0 {
+				continue
+			}
+			ranges = append(ranges, ByteRange{start: max(size-suffixLength, 0), end: size - 1})
+			continue
+		}
+
+		start, err := strconv.ParseInt(rawStart, 10, 64)
+		if err != nil || start < 0 {
+			return nil, nil
+		}
+
+		end := size - 1
+		if rawEnd != "" {
+			end, err = strconv.ParseInt(rawEnd, 10, 64)
+			if err != nil || end < start {
+				return nil, nil
+			}
+			end = min(end, size-1)
+		}
+
+		if start >= size {
+			continue
+		}
+		ranges = append(ranges, ByteRange{start: start, end: end})
+	}
+
+	if len(ranges) == 0 {
+		return nil, errRangeNotSatisfiable
+	}
+
+	return ranges, nil
+}
+
+// isIfRangeSatisfied checks validator from If-Range header against the
+// current representation. Ranges are served only when it still matches,
+// otherwise client gets the whole new content.
+func isIfRangeSatisfied(ifRange string, etag string, lastModified time.Time) bool {
+	ifRange = strings.TrimSpace(ifRange)
+	if ifRange == "" {
+		return true
+	}
+
+	// Parts of different versions can't be combined, so only strong entity
+	// tags are good enough for ranges
+	if strings.HasPrefix(ifRange, "\"") || isWeakETag(ifRange) {
+		return !isWeakETag(ifRange) && etagMatches(ifRange, etag, true)
+	}
+
+	date, ok := parseHttpDate(ifRange)
+	if !ok {
+		return false
+	}
+
+	// Dates have precision of a second
+	return date.Equal(lastModified.UTC().Truncate(time.Second))
+}
+
+// parseContentRange parses Content-Range header of request updating part of
+// content, like "bytes 10-19/*". Complete length is ignored, as the content
+// may be extended by the update.
+func parseContentRange(header string) (ByteRange, error) {
+	spec, isBytes := strings.CutPrefix(strings.TrimSpace(header), "bytes ")
+	if !isBytes {
+		return ByteRange{}, errors.New("range unit must be bytes")
+	}
+
+	rawRange, _, _ := strings.Cut(spec, "/")
+	rawStart, rawEnd, found := strings.Cut(rawRange, "-")
+	start, startErr := strconv.ParseInt(rawStart, 10, 64)
+	end, endErr := strconv.ParseInt(rawEnd, 10, 64)
+	if !found || startErr != nil || endErr != nil || start < 0 || end < start {
+		return ByteRange{}, errors.New("malformed byte range")
+	}
+
+	return ByteRange{start: start, end: end}, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var errMalformedChunkedBody = errors.New("malformed chunked request body")
+
+// Trailer fields which must not be sent in trailers, as they control
+// framing, routing or authentication of the request (RFC 9110, section 6.5.1).
+var forbiddenTrailers = map[string]bool{
+	"content-length":    true,
+	"transfer-encoding": true,
+	"host":              true,
+	"content-encoding":  true,
+	"content-type":      true,
+	"authorization":     true,
+	"trailer":           true,
+}
+
+// ChunkedBodyReader decodes body sent with "Transfer-Encoding: chunked".
+// Trailer headers, sent after the last chunk, are saved into request once
+// the whole body is read.
+type ChunkedBodyReader struct {
+	parser    *HttpRequestParser
+	request   *HttpRequest
+	remaining int64
+	// size of current chunk size line or of all trailers read so far
+	metadataSize int
+	done         bool
+	err          error
+}
+
+func (body *ChunkedBodyReader) Read(p []byte) (n int, err error) {
+	if body.err != nil {
+		return 0, body.err
+	}
+	if body.done {
+		return 0, io.EOF
+	}
+
+	if body.remaining == 0 {
+		if err := body.nextChunk(); err != nil {
+			body.err = err
+			return 0, err
+		}
+		if body.done {
+			return 0, io.EOF
+		}
+	}
+
+	if int64(len(p)) > body.remaining {
+		p = p[:body.remaining]
+	}
+
+	n, err = body.parser.reader.Read(p)
+	body.remaining -= int64(n)
+
+	if body.remaining == 0 && err == nil {
+		err = body.readChunkEnd()
+	}
+
+	if err != nil {
+		body.err = unexpectedEOF(err)
+		return n, body.err
+	}
+
+	return n, nil
+}
+
+func (body *ChunkedBodyReader) nextChunk() error {
+	body.metadataSize = 0
+	line, err := body.parser.readLine(&body.metadataSize)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+
+	// Chunk extensions are allowed after ";", but have no meaning for us
+	rawSize, _, _ := strings.Cut(line, ";")
+	rawSize = strings.TrimSpace(rawSize)
+	size, err := strconv.ParseInt(rawSize, 16, 64)
+	if err != nil || size < 0 || rawSize == "" || rawSize[0] == '+' {
+		return fmt.Errorf("%w: invalid chunk size '%s'", errMalformedChunkedBody, rawSize)
+	}
+
+	if size == 0 {
+		body.done = true
+		return body.readTrailers()
+	}
+
+	body.remaining = size
+	return nil
+}
+
+func (body *ChunkedBodyReader) readChunkEnd() error {
+	body.metadataSize = 0
+	line, err := body.parser.readLine(&body.metadataSize)
+	if err != nil {
+		return err
+	}
+	if line != "" {
+		return fmt.Errorf("%w: chunk data is longer than declared", errMalformedChunkedBody)
+	}
+	return nil
+}
+
+func (body *ChunkedBodyReader) readTrailers() error {
+	for {
+		line, err := body.parser.readLine(&body.metadataSize)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if line == "" {
+			return nil
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found || !isToken(name) {
+			return fmt.Errorf("%w: malformed trailer '%s'", errMalformedChunkedBody, line)
+		}
+
+		if forbiddenTrailers[strings.ToLower(name)] {
+			continue
+		}
+		body.request.trailers.Add(name, value)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io"
+	"log"
+	"strings"
+)
+
+// ContinueBodyReader answers "Expect: 100-continue" of request. The interim
+// "100 Continue" response is written right before the body is read for the
+// first time, so that handler can refuse the request with a final status
+// (e.g. because of failed authentication or conflict) before client sends
+// the body.
+type ContinueBodyReader struct {
+	reader   io.Reader
+	writer   io.Writer
+	response *HttpResponse
+	// continued is set once client has been told to send the body
+	continued bool
+	err       error
+}
+
+func (body *ContinueBodyReader) Read(p []byte) (int, error) {
+	if !body.continued {
+		body.continued = true
+		// Once final response is sent, client is no longer waiting for
+		// interim one
+		if !body.response.IsSent() {
+			log.Println("Sending 100 Continue...")
+			if _, err := io.WriteString(body.writer, "HTTP/1.1 100 Continue\r\n\r\n"); err != nil {
+				body.err = err
+			}
+		}
+	}
+	if body.err != nil {
+		return 0, body.err
+	}
+	return body.reader.Read(p)
+}
+
+// expectation returns value of Expect header, which is meaningful only for
+// HTTP/1.1 requests (RFC 9110, section 10.1.1).
+func (request HttpRequest) expectation() string {
+	if request.protocol == "HTTP/1.0" {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(request.GetHeader("Expect")))
+}
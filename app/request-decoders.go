@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// errUnsupportedMediaType is returned by body decoders when Content-Type of
+// request doesn't match the expected one
+var errUnsupportedMediaType = errors.New("unsupported media type of request body")
+
+// requestMediaType returns media type from Content-Type header, lowercased
+// and without parameters.
+func (request HttpRequest) requestMediaType() string {
+	mediaType, _, err := mime.ParseMediaType(request.GetHeader("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// ParseForm reads application/x-www-form-urlencoded body into form fields,
+// available with FormValue afterwards. Body is limited like with LimitedBody.
+func (request *HttpRequest) ParseForm(limit int64) error {
+	if request.requestMediaType() != "application/x-www-form-urlencoded" {
+		return errUnsupportedMediaType
+	}
+
+	body, err := request.LimitedBody(limit)
+	if err != nil {
+		return err
+	}
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	fields, err := url.ParseQuery(string(content))
+	if err != nil {
+		return fmt.Errorf("malformed form: %w", err)
+	}
+
+	if request.form == nil {
+		request.form = map[string][]string{}
+	}
+	for name, values := range fields {
+		request.form[name] = append(request.form[name], values...)
+	}
+
+	return nil
+}
+
+// DecodeJSON decodes JSON body (application/json or any "+json" type) into
+// value. Body has to contain exactly one JSON value, and is limited like with
+// LimitedBody.
+func (request HttpRequest) DecodeJSON(limit int64, value any) error {
+	mediaType := request.requestMediaType()
+	if mediaType != "application/json" && !(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")) {
+		return errUnsupportedMediaType
+	}
+
+	body, err := request.LimitedBody(limit)
+	if err != nil {
+		return err
+	}
+
+	// Errors of reading the body (like errBodyTooLarge) stay detectable, as
+	// they are wrapped
+	decoder := json.NewDecoder(body)
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("malformed JSON: %w", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		var syntaxErr *json.SyntaxError
+		if err != nil && !errors.As(err, &syntaxErr) {
+			return err
+		}
+		return errors.New("malformed JSON: unexpected data after value")
+	}
+
+	return nil
+}
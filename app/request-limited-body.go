@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+// errBodyTooLarge is returned by body reader once request body exceeds limit
+// set by handler
+var errBodyTooLarge = errors.New("request body is too large")
+
+// LimitedBodyReader reads request body until the limit is exceeded. Unlike
+// io.LimitedReader it fails instead of silently truncating the body, so that
+// handler never mistakes a part of the body for the whole of it.
+type LimitedBodyReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (body *LimitedBodyReader) Read(buffer []byte) (int, error) {
+	if body.remaining < 0 {
+		return 0, errBodyTooLarge
+	}
+
+	// One byte more than allowed is requested to tell whether body ends
+	// exactly at the limit
+	if int64(len(buffer)) > body.remaining+1 {
+		buffer = buffer[:body.remaining+1]
+	}
+
+	read, err := body.reader.Read(buffer)
+	body.remaining -= int64(read)
+	if body.remaining < 0 {
+		return read + int(body.remaining), errBodyTooLarge
+	}
+	return read, err
+}
+
+// LimitedBody returns reader of request body which fails with errBodyTooLarge
+// once more than limit bytes are read. When body is declared bigger than
+// limit by Content-Length, error is returned right away, before anything is
+// read. Limit of zero or less means that body isn't limited.
+func (request HttpRequest) LimitedBody(limit int64) (io.Reader, error) {
+	if limit <= 0 {
+		return request.body, nil
+	}
+
+	if contentLength := request.GetHeader("Content-Length"); contentLength != "" {
+		length, err := strconv.ParseInt(contentLength, 10, 64)
+		if err == nil && length > limit {
+			return nil, errBodyTooLarge
+		}
+	}
+
+	return &LimitedBodyReader{reader: request.body, remaining: limit}, nil
+}
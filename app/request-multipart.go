@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+)
+
+// Form fields bigger than this are refused, as they are kept in memory
+const maxFormFieldSize = 64 * 1024
+
+var errNotMultipart = errors.New("request body is not multipart/form-data")
+
+// IsMultipartForm reports whether request body is multipart/form-data.
+func (request HttpRequest) IsMultipartForm() bool {
+	mediaType, params, err := mime.ParseMediaType(request.GetHeader("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data" && params["boundary"] != ""
+}
+
+// ParseMultipartForm reads multipart/form-data body part by part, without
+// buffering it. File parts are passed to handleFile as they arrive, and have
+// to be consumed before the next part is read. Other fields are collected and
+// are available with FormValue afterwards. Body is limited like with
+// LimitedBody.
+func (request *HttpRequest) ParseMultipartForm(limit int64, handleFile func(part *multipart.Part) error) error {
+	mediaType, params, err := mime.ParseMediaType(request.GetHeader("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return errNotMultipart
+	}
+
+	body, err := request.LimitedBody(limit)
+	if err != nil {
+		return err
+	}
+
+	if request.form == nil {
+		request.form = map[string][]string{}
+	}
+
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if part.FileName() != "" {
+			err = handleFile(part)
+		} else {
+			err = request.readFormField(part)
+		}
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (request *HttpRequest) readFormField(part *multipart.Part) error {
+	value, err := io.ReadAll(io.LimitReader(part, maxFormFieldSize+1))
+	if err != nil {
+		return err
+	}
+	if len(value) > maxFormFieldSize {
+		return fmt.Errorf("form field '%s' is too large", part.FormName())
+	}
+
+	name := part.FormName()
+	request.form[name] = append(request.form[name], string(value))
+	return nil
+}
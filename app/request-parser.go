@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Upper bound for request line and headers together, protects the server
+// from clients which never send the empty line terminating headers.
+const maxRequestHeadersSize = 64 * 1024
+
+type RequestParseError struct {
+	code    int
+	reason  string
+	message string
+}
+
+func (err *RequestParseError) Error() string {
+	return err.message
+}
+
+func newMalformedRequestError(format string, args ...any) *RequestParseError {
+	return &RequestParseError{
+		code:    400,
+		reason:  "Bad Request",
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+// HttpRequestParser reads requests one by one from a connection. Reader is
+// kept between requests, so bytes of pipelined requests are never lost.
+type HttpRequestParser struct {
+	reader *bufio.Reader
+	server *Server
+}
+
+func newRequestParser(server *Server, conn net.Conn) *HttpRequestParser {
+	return &HttpRequestParser{
+		reader: bufio.NewReader(conn),
+		server: server,
+	}
+}
+
+// Parse reads request line and headers of the next request. Body is not read,
+// instead it is exposed via HttpRequest.Body() and has to be consumed (or
+// discarded) before next call of Parse.
+func (parser *HttpRequestParser) Parse() (*HttpRequest, error) {
+	request := &HttpRequest{
+		server:   parser.server,
+		headers:  HttpRequestHeaders{},
+		trailers: HttpRequestHeaders{},
+	}
+
+	headersSize := 0
+	requestLine := ""
+	// Empty lines before request line should be ignored (RFC 9112, section 2.2)
+	for requestLine == "" {
+		line, err := parser.readLine(&headersSize)
+		if err != nil {
+			return nil, err
+		}
+		requestLine = line
+	}
+
+	if err := request.parseRequestLine(requestLine); err != nil {
+		return nil, err
+	}
+
+	for {
+		line, err := parser.readLine(&headersSize)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if line == "" {
+			break
+		}
+		if err := request.parseHeader(line); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := parser.bodyReader(request)
+	if err != nil {
+		return nil, err
+	}
+	request.body = body
+
+	return request, nil
+}
+
+func (parser *HttpRequestParser) readLine(headersSize *int) (string, error) {
+	var line []byte
+
+	for {
+		chunk, err := parser.reader.ReadSlice('\n')
+		*headersSize += len(chunk)
+		if *headersSize > maxRequestHeadersSize {
+			return "", &RequestParseError{
+				code:    431,
+				reason:  "Request Header Fields Too Large",
+				message: "request headers are too large",
+			}
+		}
+		line = append(line, chunk...)
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				return "", io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		break
+	}
+
+	// Bare LF is tolerated as line terminator, as recommended by RFC 9112
+	line = line[:len(line)-1]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+
+	return string(line), nil
+}
+
+func (parser *HttpRequestParser) bodyReader(request *HttpRequest) (io.Reader, error) {
+	transferEncoding := request.headers.GetList("Transfer-Encoding")
+
+	rawLength := ""
+	for _, value := range request.headers.Values("Content-Length") {
+		// Repeated Content-Length is tolerated only when values are the same
+		if rawLength != "" && value != rawLength {
+			return nil, newMalformedRequestError("conflicting Content-Length headers")
+		}
+		rawLength = value
+	}
+
+	if transferEncoding != "" {
+		// Both headers at once are ambiguous, so different servers in a chain
+		// could disagree on where the request ends (request smuggling).
+		if rawLength != "" {
+			return nil, newMalformedRequestError("both Content-Length and Transfer-Encoding are set")
+		}
+		if request.protocol == "HTTP/1.0" {
+			return nil, newMalformedRequestError("Transfer-Encoding is not supported by HTTP/1.0")
+		}
+
+		codings := strings.Split(transferEncoding, ",")
+		if !strings.EqualFold(strings.TrimSpace(codings[len(codings)-1]), "chunked") {
+			return nil, newMalformedRequestError("request body must be encoded as chunked")
+		}
+		if len(codings) > 1 {
+			return nil, &RequestParseError{
+				code:    501,
+				reason:  "Not Implemented",
+				message: fmt.Sprintf("transfer coding '%s' is not supported", transferEncoding),
+			}
+		}
+
+		return &ChunkedBodyReader{parser: parser, request: request}, nil
+	}
+
+	if rawLength == "" {
+		return &io.LimitedReader{R: parser.reader, N: 0}, nil
+	}
+
+	// ParseInt accepts sign too, while only digits are valid Content-Length
+	if !isDigits(rawLength) {
+		return nil, newMalformedRequestError("invalid Content-Length '%s'", rawLength)
+	}
+	length, err := strconv.ParseInt(rawLength, 10, 64)
+	if err != nil {
+		return nil, newMalformedRequestError("invalid Content-Length '%s'", rawLength)
+	}
+
+	return &io.LimitedReader{R: parser.reader, N: length}, nil
+}
+
+func (request *HttpRequest) parseRequestLine(line string) error {
+	parts := strings.Split(line, " ")
+	if len(parts) != 3 {
+		return newMalformedRequestError("malformed request line '%s'", line)
+	}
+
+	method, target, protocol := parts[0], parts[1], parts[2]
+	if !isToken(method) {
+		return newMalformedRequestError("invalid method '%s'", method)
+	}
+	if target == "" {
+		return newMalformedRequestError("empty request target")
+	}
+	if protocol != "HTTP/1.1" && protocol != "HTTP/1.0" {
+		return newMalformedRequestError("unsupported protocol '%s'", protocol)
+	}
+
+	if err := request.parseTarget(target); err != nil {
+		return err
+	}
+
+	request.method = method
+	request.protocol = protocol
+
+	return nil
+}
+
+// parseTarget splits request target into percent-decoded path and query.
+func (request *HttpRequest) parseTarget(target string) error {
+	rawPath, rawQuery, _ := strings.Cut(target, "?")
+
+	if rawPath != "*" && !strings.HasPrefix(rawPath, "/") {
+		return newMalformedRequestError("request target '%s' must start with '/'", target)
+	}
+
+	path, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return newMalformedRequestError("invalid path '%s': %v", rawPath, err)
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return newMalformedRequestError("invalid query '%s': %v", rawQuery, err)
+	}
+
+	request.rawPath = rawPath
+	request.path = path
+	request.query = query
+
+	return nil
+}
+
+func (request *HttpRequest) parseHeader(line string) error {
+	name, value, found := strings.Cut(line, ":")
+	// Whitespace before colon and obsolete line folding are both rejected,
+	// as they are known sources of request smuggling.
+	if !found || !isToken(name) {
+		return newMalformedRequestError("malformed header line '%s'", line)
+	}
+
+	request.headers.Add(name, value)
+
+	return nil
+}
+
+// isToken checks that value is a non-empty "token" as defined in RFC 9110.
+func isToken(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, char := range value {
+		if char > 127 || char <= ' ' || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", char) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -1,49 +1,121 @@
 package main
 
 import (
+	"io"
 	"strings"
 )
 
 type HttpRequest struct {
-	method  string
-	path    string
-	body    string
-	headers HttpRequestHeaders
-	server  *Server
+	method string
+	// path is percent-decoded, while rawPath is the one sent by client
+	path     string
+	rawPath  string
+	query    map[string][]string
+	protocol string
+	body     io.Reader
+	headers  HttpRequestHeaders
+	trailers HttpRequestHeaders
+	// params are taken from the path according to the matched route pattern
+	params map[string]string
+	// paramSegments are decoded path segments matched by wildcard parameters
+	paramSegments map[string][]string
+	// form holds fields of form body, once it is parsed
+	form   map[string][]string
+	server *Server
 }
 
+// GetHeader returns value of the first header field with given name.
 func (request HttpRequest) GetHeader(name string) string {
-	return request.headers[strings.ToLower(name)]
+	return request.headers.Get(name)
 }
 
-func (request HttpRequest) AceeptsEncoding(name string) bool {
-	encodings := request.headers.GetAceeptedEncodings()
-	for _, encoding := range encodings {
-		if encoding.name == name {
-			return true
-		}
-	}
-	return false
+// GetHeaderValues returns values of all header fields with given name, e.g.
+// of several Cookie headers.
+func (request HttpRequest) GetHeaderValues(name string) []string {
+	return request.headers.Values(name)
+}
+
+// IsHead reports whether client wants only headers of the response. Handlers
+// of GET routes may use it to skip preparing content which is never sent.
+func (request HttpRequest) IsHead() bool {
+	return request.method == "HEAD"
+}
+
+// Path returns percent-decoded path of the request, without query.
+func (request HttpRequest) Path() string {
+	return request.path
 }
 
-func newRequest(server *Server, rawRequest string) (request *HttpRequest) {
-	request = &HttpRequest{
-		server: server,
+// Query returns the first value of query parameter, or empty string if
+// parameter is not passed.
+func (request HttpRequest) Query(name string) string {
+	values := request.query[name]
+	if len(values) == 0 {
+		return ""
 	}
-	requestPieces := strings.Split(rawRequest, "\r\n\r\n")
-	requestMetadataPieces := strings.Split(requestPieces[0], "\r\n")
-	request.headers = make(HttpRequestHeaders)
-
-	for _, rawHeader := range requestMetadataPieces[1:] {
-		headerPair := strings.Split(rawHeader, ":")
-		headerName := strings.ToLower(strings.Trim(headerPair[0], " "))
-		request.headers[headerName] = strings.Trim(headerPair[1], " ")
+	return values[0]
+}
+
+// QueryValues returns all values of query parameter in order they are passed.
+func (request HttpRequest) QueryValues(name string) []string {
+	return request.query[name]
+}
+
+// PathParam returns value of named parameter of the matched route pattern.
+func (request HttpRequest) PathParam(name string) string {
+	return request.params[name]
+}
+
+// PathParamSegments returns decoded path segments matched by wildcard
+// parameter. Unlike PathParam, it keeps encoded slashes apart from separators.
+func (request HttpRequest) PathParamSegments(name string) []string {
+	return request.paramSegments[name]
+}
+
+// FormValue returns the first value of form field, or empty string if field
+// is not passed or form hasn't been parsed.
+func (request HttpRequest) FormValue(name string) string {
+	values := request.form[name]
+	if len(values) == 0 {
+		return ""
 	}
+	return values[0]
+}
+
+// FormValues returns all values of form field in order they are passed.
+func (request HttpRequest) FormValues(name string) []string {
+	return request.form[name]
+}
 
-	request.method = strings.Split(requestMetadataPieces[0], " ")[0]
-	request.path = strings.Split(requestMetadataPieces[0], " ")[1]
+// Body returns reader of request body. It yields exactly as many bytes as the
+// client has declared (either by Content-Length or by chunked encoding), so it
+// never reads into the next request.
+func (request HttpRequest) Body() io.Reader {
+	return request.body
+}
+
+// GetTrailer returns header sent after chunked body. Trailers are available
+// only once the body is read completely.
+func (request HttpRequest) GetTrailer(name string) string {
+	return request.trailers.Get(name)
+}
 
-	request.body = requestPieces[1]
+// AceeptsEncoding reports whether content coding has non-zero quality in
+// Accept-Encoding header, either directly or via "*".
+func (request HttpRequest) AceeptsEncoding(name string) bool {
+	encodings := request.headers.GetAceeptedEncodings()
+	return encodingQuality(encodings, strings.ToLower(name), 0) > 0
+}
+
+// IsKeepAlive reports whether the client expects the connection to stay open
+// after the response. HTTP/1.1 connections are persistent unless the client
+// asks to close them, while HTTP/1.0 ones must opt in explicitly.
+func (request HttpRequest) IsKeepAlive() bool {
+	connection := request.headers.GetList("Connection")
+
+	if request.protocol == "HTTP/1.0" {
+		return headerHasToken(connection, "keep-alive")
+	}
 
-	return
+	return !headerHasToken(connection, "close")
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// ChunkedWriter frames everything written into it with chunked transfer
+// encoding. Every Write is sent as a separate chunk right away, so streamed
+// content reaches the client without delay.
+type ChunkedWriter struct {
+	writer *bufio.Writer
+}
+
+func NewChunkedWriter(writer io.Writer) *ChunkedWriter {
+	return &ChunkedWriter{writer: bufio.NewWriter(writer)}
+}
+
+func (chunked *ChunkedWriter) Write(p []byte) (int, error) {
+	// Zero sized chunk would mean the end of the body
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if _, err := fmt.Fprintf(chunked.writer, "%x\r\n", len(p)); err != nil {
+		return 0, err
+	}
+	n, err := chunked.writer.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if _, err := chunked.writer.WriteString("\r\n"); err != nil {
+		return n, err
+	}
+
+	return n, chunked.writer.Flush()
+}
+
+// Close sends the last chunk followed by trailers.
+func (chunked *ChunkedWriter) Close(trailers HttpResponseHeaders) error {
+	if _, err := fmt.Fprintf(chunked.writer, "0\r\n%s\r\n", trailers.String()); err != nil {
+		return err
+	}
+	return chunked.writer.Flush()
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"bytes"
-	"compress/gzip"
 	"fmt"
 	"io"
 	"log"
@@ -26,28 +25,56 @@ func (body *CompressedBody) ContentType() string {
 	return body.origin.ContentType()
 }
 
-func NewCompressedBody(body IHttpBody) IHttpBody {
+// StreamCompressedBody compresses origin body on the fly while it is being
+// sent. Length of compressed content isn't known upfront, so such body is
+// sent with chunked encoding.
+type StreamCompressedBody struct {
+	origin  IHttpBody
+	encoder IContentEncoder
+}
+
+func (body *StreamCompressedBody) WriteTo(writer io.Writer) (int64, error) {
+	counter := &countingWriter{writer: writer}
+	zw, err := body.encoder.NewWriter(counter)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := compressBody(zw, body.origin); err != nil {
+		return counter.written, err
+	}
+
+	err = zw.Close()
+	return counter.written, err
+}
+
+func (body *StreamCompressedBody) ContentType() string {
+	return body.origin.ContentType()
+}
+
+// NewCompressedBody wraps body into compression by encoder. Bodies not longer than
+// bufferLimit are compressed in memory, so Content-Length of the result is
+// known. Bigger bodies and bodies of unknown length are compressed as stream.
+func NewCompressedBody(body IHttpBody, encoder IContentEncoder, bufferLimit int) IHttpBody {
+	sizedBody, ok := body.(IHttpBodyDefinedLength)
+	if !ok || sizedBody.ContentLength() > bufferLimit {
+		log.Println("Compressing body as stream")
+		return &StreamCompressedBody{origin: body, encoder: encoder}
+	}
+
 	var buf bytes.Buffer
-	zw := gzip.NewWriter(&buf)
+	zw, err := encoder.NewWriter(&buf)
+	if err != nil {
+		log.Panicf("Couldn't create %s writer: %s", encoder.Name(), err)
+	}
 
-	switch typedBody := (body).(type) {
-	case io.Reader:
-		_, err := io.Copy(zw, typedBody)
-		if err != nil {
-			log.Panicf("Couldn't compress request content via gzip: %s", err)
-		}
-	case fmt.Stringer:
-		_, err := zw.Write([]byte(typedBody.String()))
-		if err != nil {
-			log.Panicf("Couldn't compress request content via gzip: %s", err)
-		}
-	default:
-		log.Panic("Unsupported body type for compression")
+	if err := compressBody(zw, body); err != nil {
+		log.Panicf("Couldn't compress request content via %s: %s", encoder.Name(), err)
 	}
 
 	// Close writer before getting size
 	if err := zw.Close(); err != nil {
-		log.Panicf("Couldn't close gzip writer: %s", err)
+		log.Panicf("Couldn't close %s writer: %s", encoder.Name(), err)
 	}
 
 	log.Printf("Compressed body size: %d", buf.Len())
@@ -58,3 +85,20 @@ func NewCompressedBody(body IHttpBody) IHttpBody {
 		length: buf.Len(),
 	}
 }
+
+func compressBody(writer io.Writer, body IHttpBody) error {
+	var err error
+
+	switch typedBody := (body).(type) {
+	case io.WriterTo:
+		_, err = typedBody.WriteTo(writer)
+	case io.Reader:
+		_, err = io.Copy(writer, typedBody)
+	case fmt.Stringer:
+		_, err = writer.Write([]byte(typedBody.String()))
+	default:
+		log.Panic("Unsupported body type for compression")
+	}
+
+	return err
+}
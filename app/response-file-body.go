@@ -1,27 +1,116 @@
 package main
 
 import (
+	"io"
 	"log"
 	"os"
 )
 
+// HttpFileBody sends content of a local file, or a part of it if range is
+// set. File is opened only once its content is read, so responses without
+// body (like to HEAD) need just the file metadata.
 type HttpFileBody struct {
+	path string
+	info os.FileInfo
 	file *os.File
+	// byteRange limits body to part of the file, the whole file is sent if nil
+	byteRange *ByteRange
+	reader    io.Reader
+	// contentType is sniffed from content when it can't be told by extension
+	contentType string
 }
 
 func (fileBody *HttpFileBody) Read(p []byte) (n int, err error) {
-	return fileBody.file.Read(p)
+	if fileBody.reader == nil {
+		start, length := int64(0), fileBody.info.Size()
+		if fileBody.byteRange != nil {
+			start, length = fileBody.byteRange.start, fileBody.byteRange.length()
+		}
+
+		fileBody.reader, err = fileBody.Section(start, length)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return fileBody.reader.Read(p)
 }
 
-func (fileBody *HttpFileBody) ContentLength() int {
-	fi, err := fileBody.file.Stat()
+// Section returns reader of length bytes of the file starting at offset.
+func (fileBody *HttpFileBody) Section(offset int64, length int64) (io.Reader, error) {
+	if err := fileBody.open(); err != nil {
+		return nil, err
+	}
+
+	return io.NewSectionReader(fileBody.file, offset, length), nil
+}
+
+func (fileBody *HttpFileBody) open() error {
+	if fileBody.file != nil {
+		return nil
+	}
+
+	file, err := os.Open(fileBody.path)
 	if err != nil {
-		log.Panic("Could not obtain stat, handle error")
+		return err
+	}
+	fileBody.file = file
+
+	return nil
+}
+
+// fileReader returns reader of the body which exposes underlying file, so
+// that connection can send it with zero-copy system calls.
+func (fileBody *HttpFileBody) fileReader() (*io.LimitedReader, error) {
+	start, length := int64(0), fileBody.info.Size()
+	if fileBody.byteRange != nil {
+		start, length = fileBody.byteRange.start, fileBody.byteRange.length()
+	}
+
+	if err := fileBody.open(); err != nil {
+		return nil, err
+	}
+	if _, err := fileBody.file.Seek(start, io.SeekStart); err != nil {
+		return nil, err
 	}
 
-	return int(fi.Size())
+	return &io.LimitedReader{R: fileBody.file, N: length}, nil
+}
+
+// Close releases the file, if it was opened.
+func (fileBody *HttpFileBody) Close() error {
+	if fileBody.file == nil {
+		return nil
+	}
+	return fileBody.file.Close()
+}
+
+func (fileBody *HttpFileBody) ContentLength() int {
+	if fileBody.byteRange != nil {
+		return int(fileBody.byteRange.length())
+	}
+	return int(fileBody.info.Size())
 }
 
 func (fileBody *HttpFileBody) ContentType() string {
-	return "application/octet-stream"
+	if fileBody.contentType == "" {
+		fileBody.contentType = fileBody.sniffContentType()
+	}
+	return fileBody.contentType
+}
+
+func (fileBody *HttpFileBody) sniffContentType() string {
+	sample, err := fileBody.Section(0, sniffLength)
+	if err != nil {
+		log.Printf("Couldn't read file '%s' to detect its type: %v", fileBody.path, err)
+		return "application/octet-stream"
+	}
+
+	content, err := io.ReadAll(sample)
+	if err != nil {
+		log.Printf("Couldn't read file '%s' to detect its type: %v", fileBody.path, err)
+		return "application/octet-stream"
+	}
+
+	return sniffContentType(content)
 }
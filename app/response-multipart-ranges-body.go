@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+)
+
+// HttpMultipartRangesBody sends several ranges of a file as parts of
+// multipart/byteranges content (RFC 9110, section 14.6). Only requested
+// bytes are read from disk.
+type HttpMultipartRangesBody struct {
+	file     *HttpFileBody
+	ranges   []ByteRange
+	boundary string
+}
+
+func NewMultipartRangesBody(file *HttpFileBody, ranges []ByteRange) *HttpMultipartRangesBody {
+	boundaryBytes := make([]byte, 16)
+	if _, err := rand.Read(boundaryBytes); err != nil {
+		log.Panicf("Couldn't generate multipart boundary: %s", err)
+	}
+
+	return &HttpMultipartRangesBody{
+		file:     file,
+		ranges:   ranges,
+		boundary: hex.EncodeToString(boundaryBytes),
+	}
+}
+
+func (body *HttpMultipartRangesBody) partHeader(byteRange ByteRange) string {
+	return fmt.Sprintf("\r\n--%s\r\nContent-Type: %s\r\nContent-Range: %s\r\n\r\n",
+		body.boundary, body.file.ContentType(), byteRange.contentRange(body.file.info.Size()))
+}
+
+func (body *HttpMultipartRangesBody) closingDelimiter() string {
+	return fmt.Sprintf("\r\n--%s--\r\n", body.boundary)
+}
+
+func (body *HttpMultipartRangesBody) WriteTo(writer io.Writer) (int64, error) {
+	counter := &countingWriter{writer: writer}
+
+	for _, byteRange := range body.ranges {
+		if _, err := io.WriteString(counter, body.partHeader(byteRange)); err != nil {
+			return counter.written, err
+		}
+
+		section, err := body.file.Section(byteRange.start, byteRange.length())
+		if err != nil {
+			return counter.written, err
+		}
+		if _, err := io.Copy(counter, section); err != nil {
+			return counter.written, err
+		}
+	}
+
+	_, err := io.WriteString(counter, body.closingDelimiter())
+	return counter.written, err
+}
+
+func (body *HttpMultipartRangesBody) ContentLength() int {
+	length := int64(len(body.closingDelimiter()))
+	for _, byteRange := range body.ranges {
+		length += int64(len(body.partHeader(byteRange))) + byteRange.length()
+	}
+	return int(length)
+}
+
+func (body *HttpMultipartRangesBody) ContentType() string {
+	return "multipart/byteranges; boundary=" + body.boundary
+}
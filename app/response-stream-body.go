@@ -0,0 +1,33 @@
+package main
+
+import (
+	"io"
+)
+
+// HttpStreamBody is generated while response is being sent, so its length
+// is not known in advance.
+type HttpStreamBody struct {
+	contentType string
+	producer    func(writer io.Writer) error
+}
+
+func (streamBody *HttpStreamBody) WriteTo(writer io.Writer) (int64, error) {
+	counter := &countingWriter{writer: writer}
+	err := streamBody.producer(counter)
+	return counter.written, err
+}
+
+func (streamBody *HttpStreamBody) ContentType() string {
+	return streamBody.contentType
+}
+
+type countingWriter struct {
+	writer  io.Writer
+	written int64
+}
+
+func (counter *countingWriter) Write(p []byte) (int, error) {
+	n, err := counter.writer.Write(p)
+	counter.written += int64(n)
+	return n, err
+}
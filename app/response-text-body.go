@@ -2,6 +2,8 @@ package main
 
 type HttpTextBody struct {
 	text string
+	// contentType defaults to plain text when empty
+	contentType string
 }
 
 func (textBody *HttpTextBody) String() string {
@@ -13,5 +15,8 @@ func (textBody *HttpTextBody) ContentLength() int {
 }
 
 func (textBody *HttpTextBody) ContentType() string {
-	return "plain/text"
+	if textBody.contentType != "" {
+		return textBody.contentType
+	}
+	return "text/plain; charset=utf-8"
 }
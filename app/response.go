@@ -1,9 +1,13 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -13,20 +17,107 @@ type HttpResponse struct {
 	body    IHttpBody
 	headers HttpResponseHeaders
 	sender  HttpSender
+	// trailers are sent after the body, only the ones declared in
+	// Trailer header are delivered
+	trailers HttpResponseHeaders
+	// keepAlive tells whether connection stays open once response is sent
+	keepAlive bool
+	// chunked is set when body is sent with chunked transfer encoding
+	chunked bool
+	sent    bool
+	// beforeSend hooks are called right before status line is written
+	beforeSend []func(response *HttpResponse)
 }
 
-func (response *HttpResponse) SetHeader(name string, value string) *HttpResponse {
-	if response.headers == nil {
-		response.headers = HttpResponseHeaders{}
+// OnBeforeSend registers hook called when handler sends the response, but
+// before anything is written to connection. Hooks are called in order of
+// registration and may change status, headers or body.
+func (response *HttpResponse) OnBeforeSend(hook func(response *HttpResponse)) {
+	response.beforeSend = append(response.beforeSend, hook)
+}
+
+// IsSent reports whether handler has sent the response already.
+func (response *HttpResponse) IsSent() bool {
+	return response.sent
+}
+
+// ContentType returns type of the response body, set either by handler in
+// Content-Type header or by the body itself.
+func (response *HttpResponse) ContentType() string {
+	if contentType := response.headers.Get("Content-Type"); contentType != "" {
+		return contentType
+	}
+	if response.body == nil {
+		return ""
 	}
-	formattedName := strings.ToLower(strings.Trim(name, " "))
-	response.headers[formattedName] = (strings.Trim(value, " "))
+	return response.body.ContentType()
+}
+
+// hasNoBody reports whether status of response forbids sending a body,
+// which is the case for 1xx, 204 and 304 (RFC 9110, section 6.4.1).
+func (response *HttpResponse) hasNoBody() bool {
+	code := response.StatusCode()
+	return code/100 == 1 || code == 204 || code == 304
+}
+
+// StatusCode returns numeric status of the response, or 0 if it isn't set.
+func (response *HttpResponse) StatusCode() int {
+	code, _, _ := strings.Cut(response.code, " ")
+	number, _ := strconv.Atoi(code)
+	return number
+}
 
+// SetHeader replaces all values of header with the given one.
+func (response *HttpResponse) SetHeader(name string, value string) *HttpResponse {
+	response.headers.Set(name, value)
+	return response
+}
+
+// AddHeader adds header value, keeping the ones set before. It allows to send
+// headers which can't be combined into one line, like Set-Cookie.
+func (response *HttpResponse) AddHeader(name string, value string) *HttpResponse {
+	response.headers.Add(name, value)
+	return response
+}
+
+func (response *HttpResponse) DelHeader(name string) *HttpResponse {
+	response.headers.Del(name)
 	return response
 }
 
-func (response *HttpResponse) GetHeaders() HttpResponseHeaders {
-	return response.headers
+// SetTrailer sets value of a header sent after the body. It can be called
+// while body is being streamed, but the name has to be declared in Trailer
+// header before the response is sent.
+func (response *HttpResponse) SetTrailer(name string, value string) *HttpResponse {
+	response.trailers.Set(name, value)
+	return response
+}
+
+func (response *HttpResponse) declaredTrailers() HttpResponseHeaders {
+	declaredNames := response.headers.GetList("Trailer")
+	declared := HttpResponseHeaders{}
+	for _, field := range response.trailers.fields {
+		if headerHasToken(declaredNames, field.name) {
+			declared.Add(field.name, field.value)
+		}
+	}
+	return declared
+}
+
+// AddVary adds request header name to Vary header, unless it is listed already.
+func (response *HttpResponse) AddVary(name string) *HttpResponse {
+	vary := response.headers.GetList("Vary")
+	if headerHasToken(vary, name) {
+		return response
+	}
+	if vary != "" {
+		name = vary + ", " + name
+	}
+	return response.SetHeader("Vary", name)
+}
+
+func (response *HttpResponse) GetHeaders() *HttpResponseHeaders {
+	return &response.headers
 }
 
 func (response *HttpResponse) Status(code int, message string) *HttpResponse {
@@ -44,11 +135,57 @@ func (response *HttpResponse) Status501() *HttpResponse {
 	return response
 }
 
+func (response *HttpResponse) Status204() *HttpResponse {
+	response.code = "204 No Content"
+	return response
+}
+
+func (response *HttpResponse) Status304() *HttpResponse {
+	response.code = "304 Not Modified"
+	return response
+}
+
+func (response *HttpResponse) Status412() *HttpResponse {
+	response.code = "412 Precondition Failed"
+	return response
+}
+
+func (response *HttpResponse) Status406() *HttpResponse {
+	response.code = "406 Not Acceptable"
+	return response
+}
+
+func (response *HttpResponse) Status403() *HttpResponse {
+	response.code = "403 Forbidden"
+	return response
+}
+
 func (response *HttpResponse) Status404() *HttpResponse {
 	response.code = "404 Not Found"
 	return response
 }
 
+// Status408 tells that request hasn't been received in time. Connection is
+// closed afterwards, as the rest of request may still arrive.
+func (response *HttpResponse) Status408() *HttpResponse {
+	response.code = "408 Request Timeout"
+	response.keepAlive = false
+	return response
+}
+
+// Status413 rejects request body exceeding the limit. Connection is closed
+// afterwards, as reading the rest of such body could take long.
+func (response *HttpResponse) Status413() *HttpResponse {
+	response.code = "413 Payload Too Large"
+	response.keepAlive = false
+	return response
+}
+
+func (response *HttpResponse) Status415() *HttpResponse {
+	response.code = "415 Unsupported Media Type"
+	return response
+}
+
 func (response *HttpResponse) Status500() *HttpResponse {
 	response.code = "500 Internal Server Error"
 	return response
@@ -75,23 +212,111 @@ func (response *HttpResponse) Text(text string) {
 	response.Send()
 }
 
+// JSON sends value encoded as JSON.
+func (response *HttpResponse) JSON(value any) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		log.Panicf("Couldn't encode response as JSON: %s", err)
+	}
+
+	body := HttpTextBody{text: string(content), contentType: "application/json"}
+	response.Body(&body)
+	response.Send()
+}
+
+// RequestBodyError responds to request which body couldn't be decoded by
+// ParseForm, DecodeJSON or ParseMultipartForm, or whose body stalled.
+func (response *HttpResponse) RequestBodyError(err error) {
+	switch {
+	case errors.Is(err, errUnsupportedMediaType), errors.Is(err, errNotMultipart):
+		response.Status415().Text(err.Error())
+	case errors.Is(err, errBodyTooLarge):
+		response.Status413().Text(err.Error())
+	case isTimeoutError(err):
+		response.Status408().Text("Request body wasn't received in time")
+	default:
+		response.Status400().Text(err.Error())
+	}
+}
+
+// Stream sends body of unknown length, which is written by producer while
+// response is being sent. Body is delivered in chunks to HTTP/1.1 clients.
+func (response *HttpResponse) Stream(contentType string, producer func(writer io.Writer) error) {
+	body := HttpStreamBody{
+		contentType: contentType,
+		producer:    producer,
+	}
+	response.Body(&body)
+	response.Send()
+}
+
 func (response *HttpResponse) LocalFile(pathToFile string) {
-	file, err := os.Open(pathToFile)
+	info, err := os.Stat(pathToFile)
 
 	if err != nil {
 		log.Panicf("Couldn't open a file '%s'.", pathToFile)
 	}
 
-	defer file.Close()
-
 	body := HttpFileBody{
-		file: file,
+		path:        pathToFile,
+		info:        info,
+		contentType: response.request.server.config.mimeTypes.TypeByExtension(pathToFile),
+	}
+
+	defer body.Close()
+
+	etag := fileETag(info, *response.request.server.config.weakETags)
+
+	response.SetHeader("Accept-Ranges", "bytes")
+	response.SetHeader("Last-Modified", formatHttpDate(info.ModTime()))
+	response.SetHeader("ETag", etag)
+
+	switch evaluatePreconditions(response.request, etag, info.ModTime()) {
+	case 304:
+		response.Status304().Send()
+		return
+	case 412:
+		response.Status412().Send()
+		return
+	}
+
+	if response.request.GetHeader("Range") != "" && response.StatusCode() == 200 {
+		response.sendFileRanges(&body)
+		return
 	}
 
 	response.Body(&body)
 	response.Send()
 }
 
+// sendFileRanges responds with parts of file requested in Range header. Range
+// is ignored (and the whole file is sent) when it is malformed, or when file
+// has changed since the version referenced by If-Range.
+func (response *HttpResponse) sendFileRanges(body *HttpFileBody) {
+	request := response.request
+	size := body.info.Size()
+
+	ranges, err := parseRangeHeader(request.GetHeader("Range"), size)
+	etag := response.headers.Get("ETag")
+	if !isIfRangeSatisfied(request.GetHeader("If-Range"), etag, body.info.ModTime()) {
+		ranges, err = nil, nil
+	}
+
+	switch {
+	case err != nil:
+		response.SetHeader("Content-Range", fmt.Sprintf("bytes */%d", size))
+		response.Status(416, "Range Not Satisfiable").Send()
+	case len(ranges) == 1:
+		body.byteRange = &ranges[0]
+		response.SetHeader("Content-Range", ranges[0].contentRange(size))
+		response.Status(206, "Partial Content").Body(body).Send()
+	case len(ranges) > 1:
+		response.Status(206, "Partial Content").Body(NewMultipartRangesBody(body, ranges)).Send()
+	default:
+		response.Body(body).Send()
+	}
+}
+
 func (response *HttpResponse) Send() {
 	response.sender.SendAll(response)
 }
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type HttpHandler func(request *HttpRequest, response *HttpResponse)
+
+// routeSegment is a piece of route pattern between slashes. It either has to
+// be equal to the path segment, or captures it into a named parameter.
+type routeSegment struct {
+	literal string
+	param   string
+	// wildcard parameter captures all remaining segments of the path
+	wildcard bool
+}
+
+type route struct {
+	method   string
+	pattern  string
+	segments []routeSegment
+	handler  HttpHandler
+}
+
+// Router dispatches requests to handlers by method and path. Patterns may
+// contain named parameters, like "/files/{name}", and trailing wildcard
+// parameters, like "/echo/{text...}", which match the rest of the path.
+// Routes are matched in order of registration.
+type Router struct {
+	routes []*route
+	// middlewares wrap all requests, including the ones without route
+	middlewares []Middleware
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Use adds middlewares applied to every request served by router.
+func (router *Router) Use(middlewares ...Middleware) {
+	router.middlewares = append(router.middlewares, middlewares...)
+}
+
+// Handle registers handler of the route, wrapped by route specific middlewares.
+func (router *Router) Handle(method string, pattern string, handler HttpHandler, middlewares ...Middleware) {
+	router.routes = append(router.routes, &route{
+		method:   strings.ToUpper(method),
+		pattern:  pattern,
+		segments: parseRoutePattern(pattern),
+		handler:  chainMiddlewares(handler, middlewares),
+	})
+}
+
+// Group creates set of routes sharing path prefix and middlewares.
+func (router *Router) Group(prefix string, middlewares ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		router:      router,
+		prefix:      strings.TrimSuffix(prefix, "/"),
+		middlewares: middlewares,
+	}
+}
+
+type RouteGroup struct {
+	router      *Router
+	prefix      string
+	middlewares []Middleware
+}
+
+// Use adds middlewares applied to routes registered in group afterwards.
+func (group *RouteGroup) Use(middlewares ...Middleware) {
+	group.middlewares = append(group.middlewares, middlewares...)
+}
+
+// Handle registers route with pattern relative to group prefix. Group
+// middlewares wrap the route specific ones.
+func (group *RouteGroup) Handle(method string, pattern string, handler HttpHandler, middlewares ...Middleware) {
+	allMiddlewares := append(append([]Middleware{}, group.middlewares...), middlewares...)
+	group.router.Handle(method, group.prefix+pattern, handler, allMiddlewares...)
+}
+
+func (group *RouteGroup) Group(prefix string, middlewares ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		router:      group.router,
+		prefix:      group.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: append(append([]Middleware{}, group.middlewares...), middlewares...),
+	}
+}
+
+func parseRoutePattern(pattern string) []routeSegment {
+	if !strings.HasPrefix(pattern, "/") {
+		log.Panicf("Route pattern '%s' must start with '/'", pattern)
+	}
+
+	rawSegments := strings.Split(pattern[1:], "/")
+	segments := make([]routeSegment, 0, len(rawSegments))
+
+	for index, rawSegment := range rawSegments {
+		name, isParam := strings.CutPrefix(rawSegment, "{")
+		if !isParam {
+			segments = append(segments, routeSegment{literal: rawSegment})
+			continue
+		}
+
+		name, closed := strings.CutSuffix(name, "}")
+		if !closed || name == "" {
+			log.Panicf("Malformed parameter '%s' in route pattern '%s'", rawSegment, pattern)
+		}
+
+		name, wildcard := strings.CutSuffix(name, "...")
+		if wildcard && index != len(rawSegments)-1 {
+			log.Panicf("Wildcard parameter must be the last one in route pattern '%s'", pattern)
+		}
+
+		segments = append(segments, routeSegment{param: name, wildcard: wildcard})
+	}
+
+	return segments
+}
+
+// splitPath splits raw path into percent-decoded segments. Splitting is done
+// before decoding, so encoded slash stays a part of segment.
+func splitPath(rawPath string) []string {
+	segments := strings.Split(strings.TrimPrefix(rawPath, "/"), "/")
+	for index, segment := range segments {
+		// Path was validated by request parser, so decoding can't fail
+		segments[index], _ = url.PathUnescape(segment)
+	}
+	return segments
+}
+
+// match checks whether path segments fit route pattern and extracts its
+// parameters. Segments matched by wildcard are returned separately too, as
+// joined value can't tell encoded slashes from separators.
+func (route *route) match(pathSegments []string) (map[string]string, map[string][]string, bool) {
+	params := map[string]string{}
+	paramSegments := map[string][]string{}
+
+	for index, segment := range route.segments {
+		if segment.wildcard {
+			params[segment.param] = strings.Join(pathSegments[index:], "/")
+			paramSegments[segment.param] = pathSegments[index:]
+			return params, paramSegments, true
+		}
+		if index >= len(pathSegments) {
+			return nil, nil, false
+		}
+
+		pathSegment := pathSegments[index]
+		if segment.param != "" {
+			if pathSegment == "" {
+				return nil, nil, false
+			}
+			params[segment.param] = pathSegment
+		} else if segment.literal != pathSegment {
+			return nil, nil, false
+		}
+	}
+
+	return params, paramSegments, len(pathSegments) == len(route.segments)
+}
+
+// ServeHttp passes request through router middlewares to matching route.
+func (router *Router) ServeHttp(request *HttpRequest, response *HttpResponse) {
+	chainMiddlewares(router.dispatch, router.middlewares)(request, response)
+}
+
+// dispatch calls handler of route matching the request. HEAD requests are
+// served by GET handlers unless HEAD route is registered explicitly, OPTIONS
+// requests are answered with the list of allowed methods.
+func (router *Router) dispatch(request *HttpRequest, response *HttpResponse) {
+	if request.method == "OPTIONS" && request.path == "*" {
+		response.SetHeader("Allow", router.allowedMethods(router.routes))
+		response.Status200().Send()
+		return
+	}
+
+	pathSegments := splitPath(request.rawPath)
+	matchingRoutes := []*route{}
+	for _, route := range router.routes {
+		if params, paramSegments, ok := route.match(pathSegments); ok {
+			request.params = params
+			request.paramSegments = paramSegments
+			if route.method == request.method {
+				route.handler(request, response)
+				return
+			}
+			matchingRoutes = append(matchingRoutes, route)
+		}
+	}
+
+	if len(matchingRoutes) == 0 {
+		response.Status404().Send()
+		return
+	}
+
+	allowed := router.allowedMethods(matchingRoutes)
+
+	switch request.method {
+	case "HEAD":
+		for _, route := range matchingRoutes {
+			if route.method == "GET" {
+				request.params, request.paramSegments, _ = route.match(pathSegments)
+				route.handler(request, response)
+				return
+			}
+		}
+	case "OPTIONS":
+		response.SetHeader("Allow", allowed)
+		response.Status200().Send()
+		return
+	}
+
+	response.SetHeader("Allow", allowed)
+	response.Status(405, "Method Not Allowed").Text(fmt.Sprintf("Method %s is not allowed", request.method))
+}
+
+func (router *Router) allowedMethods(routes []*route) string {
+	methods := map[string]bool{"OPTIONS": true}
+	for _, route := range routes {
+		methods[route.method] = true
+		if route.method == "GET" {
+			methods["HEAD"] = true
+		}
+	}
+
+	allowed := make([]string, 0, len(methods))
+	for method := range methods {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+
+	return strings.Join(allowed, ", ")
+}
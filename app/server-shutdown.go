@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// connectionTracker keeps open connections of server, so that they can be
+// drained on shutdown. Zero value is ready to use.
+type connectionTracker struct {
+	mutex    sync.Mutex
+	listener net.Listener
+	// idle tells whether connection is waiting for the next request
+	idle         map[net.Conn]bool
+	shuttingDown bool
+	active       sync.WaitGroup
+}
+
+// setListener remembers listener accepting connections, so that Shutdown can
+// close it. Listener is closed right away when shutdown has already begun.
+func (server *Server) setListener(listener net.Listener) {
+	server.connections.mutex.Lock()
+	defer server.connections.mutex.Unlock()
+
+	server.connections.listener = listener
+	if server.connections.shuttingDown {
+		listener.Close()
+	}
+}
+
+// trackConn registers accepted connection. When server is shutting down,
+// false is returned and connection has to be closed.
+func (server *Server) trackConn(conn net.Conn) bool {
+	tracker := &server.connections
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if tracker.shuttingDown {
+		return false
+	}
+	if tracker.idle == nil {
+		tracker.idle = map[net.Conn]bool{}
+	}
+	tracker.idle[conn] = false
+	tracker.active.Add(1)
+	return true
+}
+
+func (server *Server) untrackConn(conn net.Conn) {
+	tracker := &server.connections
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	delete(tracker.idle, conn)
+	tracker.active.Done()
+}
+
+// setConnIdle marks connection as waiting for the next request or as serving
+// one. Connection can't become idle once server is shutting down, false is
+// returned then.
+func (server *Server) setConnIdle(conn net.Conn, idle bool) bool {
+	tracker := &server.connections
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if idle && tracker.shuttingDown {
+		return false
+	}
+	tracker.idle[conn] = idle
+	return true
+}
+
+// IsShuttingDown reports whether Shutdown has been called.
+func (server *Server) IsShuttingDown() bool {
+	server.connections.mutex.Lock()
+	defer server.connections.mutex.Unlock()
+	return server.connections.shuttingDown
+}
+
+// Shutdown stops accepting connections, closes idle keep-alive ones and waits
+// until requests being served are finished, after which their connections
+// are closed too. When context is done first, remaining connections are
+// closed forcibly and context error is returned.
+func (server *Server) Shutdown(ctx context.Context) error {
+	tracker := &server.connections
+
+	tracker.mutex.Lock()
+	tracker.shuttingDown = true
+	if tracker.listener != nil {
+		tracker.listener.Close()
+	}
+	for conn, idle := range tracker.idle {
+		if idle {
+			conn.Close()
+		}
+	}
+	tracker.mutex.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		tracker.active.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		tracker.mutex.Lock()
+		for conn := range tracker.idle {
+			conn.Close()
+		}
+		tracker.mutex.Unlock()
+		return ctx.Err()
+	}
+}
@@ -1,23 +1,63 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"io/fs"
+	"io"
 	"log"
 	"net"
 	"os"
-	"path"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 )
 
 type ServerConfig struct {
 	filesDirectory *string
 	port           *int
+	idleTimeout    *time.Duration
+	// request line and headers have to be received within headerTimeout
+	headerTimeout *time.Duration
+	// each read of request body and each write of response has to make
+	// progress within these timeouts
+	bodyTimeout        *time.Duration
+	writeTimeout       *time.Duration
+	maxRequestsPerConn *int
+	// how long active connections may take to finish on shutdown
+	drainTimeout *time.Duration
+	// bodies bigger than this are compressed on the fly instead of in memory
+	compressionBufferLimit *int
+	compressionPolicy      *CompressionPolicy
+	mimeTypes              *MimeTypes
+	// files directory is listed at /files/
+	directoryIndex *bool
+	// ETags of files are weak, i.e. claim only semantic equivalence of content
+	weakETags *bool
+	// uploads bigger than this are refused with 413, 0 means unlimited
+	maxUploadSize *int64
+	// "user:password" required to upload files, uploads are public when empty
+	uploadCredentials *string
 }
 
 type Server struct {
 	config ServerConfig
+	router *Router
+	// encoders available for compression of responses, in order of preference
+	encoders    []IContentEncoder
+	connections connectionTracker
+	metrics     ServerMetrics
+	// fileLocks serialize edits of the same file
+	fileLocks fileLocks
+}
+
+// RegisterEncoder makes content coding available for responses. Encoders
+// registered earlier are preferred when client accepts several codings with
+// the same quality.
+func (server *Server) RegisterEncoder(encoder IContentEncoder) {
+	server.encoders = append(server.encoders, encoder)
 }
 
 func main() {
@@ -27,17 +67,82 @@ func main() {
 	config := ServerConfig{
 		filesDirectory: flag.String("directory", "", "Directory with files for endpoint /files"),
 		port:           flag.Int("port", 4221, "Port to listen on"),
+		idleTimeout: flag.Duration("idle-timeout", 60*time.Second,
+			"How long a keep-alive connection may stay idle waiting for the next request"),
+		headerTimeout: flag.Duration("header-timeout", 10*time.Second,
+			"How long client may take to send request line and headers, and to start the first request"),
+		bodyTimeout: flag.Duration("body-timeout", 30*time.Second,
+			"How long reading of request body may stall before request fails with 408"),
+		writeTimeout: flag.Duration("write-timeout", 30*time.Second,
+			"How long writing of response may stall before connection is closed"),
+		maxRequestsPerConn: flag.Int("max-requests", 100,
+			"Maximum number of requests served over a single connection (0 means unlimited)"),
+		drainTimeout: flag.Duration("drain-timeout", 30*time.Second,
+			"How long requests being served may take to finish on shutdown"),
+		directoryIndex: flag.Bool("directory-index", false,
+			"List files directory at /files/ as HTML, JSON or plain text"),
+		weakETags: flag.Bool("weak-etags", false, "Send weak ETags of files instead of strong ones"),
+		maxUploadSize: flag.Int64("max-upload-size", 0,
+			"Maximum size in bytes of uploaded file (0 means unlimited)"),
+		uploadCredentials: flag.String("upload-credentials", "",
+			"Credentials in form 'user:password' required to upload files with Basic authentication"),
+		compressionBufferLimit: flag.Int("compression-buffer-limit", 64*1024,
+			"Maximum size in bytes of a body compressed in memory to send it with Content-Length"),
 	}
 
+	compressionMinSize := flag.Int("compression-min-size", 512,
+		"Responses smaller than this number of bytes are not compressed")
+	compressionAllow := flag.String("compression-allow", "",
+		"Comma separated content types to compress, all types are allowed when empty")
+	compressionDeny := flag.String("compression-deny", "",
+		"Comma separated content types never compressed, well known compressed formats when empty")
+
+	mimeTypesPath := flag.String("mime-types", "",
+		"File in mime.types format with content types of file extensions, added to built-in ones")
+
 	flag.Parse()
 
-	server := Server{
+	mimeTypes, err := NewMimeTypes(*mimeTypesPath)
+	if err != nil {
+		fmt.Printf("Failed to load MIME types: %v\n", err)
+		os.Exit(1)
+	}
+	config.mimeTypes = mimeTypes
+
+	config.compressionPolicy = NewCompressionPolicy(*compressionMinSize, *compressionAllow, *compressionDeny)
+
+	server := &Server{
 		config: config,
+		router: NewRouter(),
 	}
+	registerRoutes(server.router, config)
+	server.RegisterEncoder(&GzipEncoder{})
+	server.RegisterEncoder(&DeflateEncoder{})
 
-	startServer(&server)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go startServer(server)
+
+	received := <-signals
+	fmt.Printf("Received %s, shutting down...\n", received)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *config.drainTimeout)
+	defer cancel()
+	// Another signal stops waiting for connections
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	if err := server.Shutdown(ctx); err != nil {
+		fmt.Printf("Connections closed forcibly: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Server stopped")
 }
 
+// startServer accepts connections until server is shut down.
 func startServer(server *Server) {
 	port := *(server.config.port)
 	address := fmt.Sprintf("0.0.0.0:%d", port)
@@ -51,20 +156,33 @@ func startServer(server *Server) {
 
 	// Ensure we teardown the server when the program exits
 	defer listener.Close()
+	server.setListener(listener)
 
 	for {
 		// Block until we receive an incoming connection
 		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			fmt.Println("Stopped accepting connections")
+			return
+		}
 		if err != nil {
 			fmt.Println("Error accepting connection: ", err.Error())
+			continue
+		}
+
+		server.metrics.connectionsAccepted.Add(1)
+		if !server.trackConn(conn) {
+			conn.Close()
+			continue
 		}
 
 		// Handle client connection
-		go handleConn(conn, *server)
+		go handleConn(conn, server)
 	}
 }
 
-func handleConn(conn net.Conn, server Server) {
+func handleConn(conn net.Conn, server *Server) {
+	defer server.untrackConn(conn)
 	defer func() {
 		if err := recover(); err != nil {
 			log.Printf("Unhandled error in connection: %v", err)
@@ -72,41 +190,187 @@ func handleConn(conn net.Conn, server Server) {
 	}()
 	defer conn.Close()
 
-	buf := make([]byte, 1024)
-	input, err := conn.Read(buf)
+	timeoutConn := &TimeoutConn{Conn: conn, server: server}
+	parser := newRequestParser(server, timeoutConn)
+	maxRequests := *server.config.maxRequestsPerConn
 
-	if err != nil {
-		fmt.Println("Error reading input: ", err.Error())
-		return
+	for served := 1; ; served++ {
+		if !server.setConnIdle(conn, true) {
+			log.Println("Closing idle connection on shutdown")
+			return
+		}
+
+		// Waiting for the next request is bounded by idle timeout, so abandoned
+		// keep-alive connections don't hold goroutines forever. Client which
+		// connects and sends nothing is given just header timeout.
+		if served == 1 {
+			timeoutConn.waitRequest(*server.config.headerTimeout)
+		} else {
+			timeoutConn.waitRequest(*server.config.idleTimeout)
+		}
+
+		// Connection stays idle until the first byte of request arrives, so
+		// shutdown doesn't cut request being received
+		_, err := parser.reader.Peek(1)
+		server.setConnIdle(conn, false)
+
+		var request *HttpRequest
+		if err == nil {
+			timeoutConn.readHeaders()
+			request, err = parser.Parse()
+		}
+
+		if err != nil {
+			var netErr net.Error
+			var parseErr *RequestParseError
+			if server.IsShuttingDown() && errors.Is(err, net.ErrClosed) {
+				log.Println("Closed idle connection on shutdown")
+			} else if errors.Is(err, io.EOF) {
+				log.Println("Connection closed by client")
+			} else if errors.As(err, &netErr) && netErr.Timeout() && timeoutConn.phase == phaseHeaders {
+				sendParseError(timeoutConn, server, &RequestParseError{
+					code:    408,
+					reason:  "Request Timeout",
+					message: "Request headers weren't received in time",
+				})
+			} else if errors.As(err, &netErr) && netErr.Timeout() {
+				log.Println("Closing idle connection")
+			} else if errors.As(err, &parseErr) {
+				log.Printf("Rejecting malformed request: %v", parseErr)
+				sendParseError(timeoutConn, server, parseErr)
+			} else {
+				fmt.Println("Error reading input: ", err.Error())
+			}
+			return
+		}
+
+		timeoutConn.readBody()
+		server.metrics.requestsServed.Add(1)
+
+		sender := HttpSender{conn: timeoutConn}
+
+		response := &HttpResponse{
+			sender:    sender,
+			request:   request,
+			keepAlive: request.IsKeepAlive() && (maxRequests <= 0 || served < maxRequests),
+		}
+		// Client is told to not reuse connection which is about to be closed
+		response.OnBeforeSend(func(response *HttpResponse) {
+			if server.IsShuttingDown() {
+				response.keepAlive = false
+			}
+		})
+
+		// Rest of the body left by handler is skipped before response is sent,
+		// so the next request starts at the right position of the stream.
+		// Client is told to close the connection when too much of it is left.
+		discardBody := func(response *HttpResponse) {
+			if !response.keepAlive {
+				return
+			}
+			discarded, err := io.CopyN(io.Discard, request.Body(), maxLingeringBodySize+1)
+			if err != nil && !errors.Is(err, io.EOF) {
+				log.Printf("Failed to discard request body: %v", err)
+				response.keepAlive = false
+			} else if discarded > maxLingeringBodySize {
+				response.keepAlive = false
+			}
+		}
+
+		// whether client sends the body without waiting for 100 Continue
+		bodyIncoming := true
+
+		switch request.expectation() {
+		case "":
+			response.OnBeforeSend(discardBody)
+			server.router.ServeHttp(request, response)
+		case "100-continue":
+			continueBody := &ContinueBodyReader{reader: request.body, writer: timeoutConn, response: response}
+			request.body = continueBody
+			response.OnBeforeSend(func(response *HttpResponse) {
+				// Client which wasn't told to continue may or may not send
+				// the body, so the next request couldn't be found reliably
+				if !continueBody.continued {
+					response.keepAlive = false
+				}
+			})
+			response.OnBeforeSend(discardBody)
+			server.router.ServeHttp(request, response)
+			bodyIncoming = continueBody.continued
+		default:
+			// Body may follow anyway, so connection can't be reused
+			response.keepAlive = false
+			response.Status(417, "Expectation Failed").Text("Only 100-continue expectation is supported")
+		}
+
+		if !response.IsSent() {
+			log.Printf("Handler of %s %s hasn't sent response", request.method, request.path)
+			response.Status500().Send()
+		}
+
+		if !response.keepAlive {
+			if bodyIncoming {
+				lingerBeforeClose(timeoutConn, request)
+			}
+			return
+		}
 	}
+}
 
-	inputStr := string(buf[:input])
-	log.Printf("Received request with %d bytes: \n%s", input, inputStr)
+// Limits of reading unread request body before the next request or before
+// connection is closed
+const (
+	maxLingeringBodySize = 256 * 1024
+	lingeringTimeout     = 2 * time.Second
+)
 
-	request := newRequest(&server, inputStr)
-	sender := HttpSender{conn: conn}
+// lingerBeforeClose reads (a bounded part of) request body left by handler.
+// Closing connection with unread data makes the kernel reset it, which may
+// destroy response before client reads it, e.g. when upload is refused with
+// 413 while client is still sending it.
+func lingerBeforeClose(conn *TimeoutConn, request *HttpRequest) {
+	conn.linger(lingeringTimeout)
+	io.CopyN(io.Discard, request.Body(), maxLingeringBodySize)
+}
+
+// sendParseError responds to request which couldn't be parsed. Connection
+// is closed afterwards, as there is no way to find where next request starts.
+func sendParseError(conn net.Conn, server *Server, parseErr *RequestParseError) {
+	request := &HttpRequest{
+		server:   server,
+		protocol: "HTTP/1.1",
+		headers:  HttpRequestHeaders{},
+		body:     strings.NewReader(""),
+	}
 
 	response := &HttpResponse{
-		sender:  sender,
+		sender:  HttpSender{conn: conn},
 		request: request,
 	}
 
-	routeRequest(request, response)
+	response.Status(parseErr.code, parseErr.reason).Text(parseErr.message)
 }
 
-func routeRequest(request *HttpRequest, response *HttpResponse) {
-	switch {
-	case request.path == "/":
-		routeRoot(request, response)
-	case strings.HasPrefix(request.path, "/echo/"):
-		routeEcho(request, response)
-	case strings.HasPrefix(request.path, "/user-agent"):
-		routeUserAgent(request, response)
-	case strings.HasPrefix(request.path, "/files"):
-		routeFiles(request, response)
-	default:
-		response.Status404().Send()
+func registerRoutes(router *Router, config ServerConfig) {
+	router.Use(RecoveryMiddleware, LoggingMiddleware, TimingMiddleware, CompressionMiddleware)
+
+	router.Handle("GET", "/", routeRoot)
+	router.Handle("GET", "/echo/{text...}", routeEcho)
+	router.Handle("POST", "/echo", routeEchoBody)
+	router.Handle("GET", "/user-agent", routeUserAgent)
+	router.Handle("GET", "/metrics", routeMetrics)
+
+	uploadMiddlewares := []Middleware{}
+	if username, password, ok := strings.Cut(*config.uploadCredentials, ":"); ok {
+		uploadMiddlewares = append(uploadMiddlewares, BasicAuthMiddleware("files", username, password))
 	}
+
+	files := router.Group("/files")
+	files.Handle("GET", "/{path...}", getFileRoute)
+	files.Handle("POST", "/{path...}", postFileRoute, uploadMiddlewares...)
+	files.Handle("PUT", "/{path...}", putFileRoute, uploadMiddlewares...)
+	files.Handle("PATCH", "/{path...}", patchFileRoute, uploadMiddlewares...)
+	files.Handle("DELETE", "/{path...}", deleteFileRoute, uploadMiddlewares...)
 }
 
 func routeRoot(request *HttpRequest, response *HttpResponse) {
@@ -114,56 +378,32 @@ func routeRoot(request *HttpRequest, response *HttpResponse) {
 }
 
 func routeEcho(request *HttpRequest, response *HttpResponse) {
-	parameter, _ := strings.CutPrefix(request.path, "/echo/")
-	response.Status200().Text(parameter)
-}
-
-func routeUserAgent(request *HttpRequest, response *HttpResponse) {
-	response.Status200().Text(request.GetHeader("User-Agent"))
-}
-
-func routeFiles(request *HttpRequest, response *HttpResponse) {
-	if request.method == "GET" {
-		getFileRoute(request, response)
-	} else if request.method == "POST" {
-		postFileRoute(request, response)
-	} else {
-		response.Status404().Text("Method not allowed")
-	}
+	response.Status200().Text(request.PathParam("text"))
 }
 
-func getFile(request *HttpRequest, response *HttpResponse) {
-	fileName, _ := strings.CutPrefix(request.path, "/files/")
+// Bodies bigger than this are not echoed, as they are decoded in memory
+const maxEchoBodySize = 64 * 1024
 
-	if fileName == "" {
-		response.Status404().Text("Name of file is not passed in URL")
-		return
-	}
-
-	filesDirectory := *request.server.config.filesDirectory
-	files, err := os.ReadDir(filesDirectory)
-	if err != nil {
-		log.Print(err)
-		response.Status500().Text("File server feature is not available!")
-		return
-	}
-
-	var targetFile fs.DirEntry
-	for _, file := range files {
-		if file.Name() == fileName && !file.IsDir() {
-			targetFile = file
-			break
+// routeEchoBody responds with fields of url-encoded form or with JSON value
+// sent in request body, encoded as JSON.
+func routeEchoBody(request *HttpRequest, response *HttpResponse) {
+	if request.requestMediaType() == "application/x-www-form-urlencoded" {
+		if err := request.ParseForm(maxEchoBodySize); err != nil {
+			response.RequestBodyError(err)
+			return
 		}
+		response.Status200().JSON(request.form)
+		return
 	}
 
-	if targetFile == nil {
-		log.Printf("Requested file '%s' doesn't exists in folder '$s'", fileName, filesDirectory)
-		response.Status404().Text("Requested file not found")
+	var value any
+	if err := request.DecodeJSON(maxEchoBodySize, &value); err != nil {
+		response.RequestBodyError(err)
 		return
 	}
+	response.Status200().JSON(value)
+}
 
-	fullFilePath := path.Join(filesDirectory, fileName)
-
-	response.SetHeader("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileName))
-	response.Status200().LocalFile(fullFilePath)
+func routeUserAgent(request *HttpRequest, response *HttpResponse) {
+	response.Status200().Text(request.GetHeader("User-Agent"))
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"time"
+)
+
+// Files are sent in segments of this size, each one bounded by write timeout
+const sendFileSegmentSize = 1024 * 1024
+
+// What connection is waiting for, used to tell which timeout has expired
+type connPhase int
+
+const (
+	phaseIdle connPhase = iota
+	phaseHeaders
+	phaseBody
+	phaseLingering
+)
+
+// TimeoutConn applies timeouts of server to connection. Every write has to
+// make progress within write timeout. Reads of request body have to make
+// progress within body timeout, while deadlines of waiting for request and
+// reading its headers are set by connection handler as a whole.
+type TimeoutConn struct {
+	net.Conn
+	server *Server
+	phase  connPhase
+}
+
+// waitRequest bounds time until request starts to arrive.
+func (conn *TimeoutConn) waitRequest(timeout time.Duration) {
+	conn.phase = phaseIdle
+	conn.SetReadDeadline(time.Now().Add(timeout))
+}
+
+// readHeaders bounds time to receive request line and headers.
+func (conn *TimeoutConn) readHeaders() {
+	conn.phase = phaseHeaders
+	conn.SetReadDeadline(time.Now().Add(*conn.server.config.headerTimeout))
+}
+
+// readBody switches to timeout applied to each read of body.
+func (conn *TimeoutConn) readBody() {
+	conn.phase = phaseBody
+}
+
+// linger bounds time of reading unread body before connection is closed.
+func (conn *TimeoutConn) linger(timeout time.Duration) {
+	conn.phase = phaseLingering
+	conn.SetReadDeadline(time.Now().Add(timeout))
+}
+
+func (conn *TimeoutConn) Read(p []byte) (int, error) {
+	if conn.phase == phaseBody {
+		conn.SetReadDeadline(time.Now().Add(*conn.server.config.bodyTimeout))
+	}
+
+	n, err := conn.Conn.Read(p)
+	if isTimeoutError(err) {
+		metrics := &conn.server.metrics
+		switch conn.phase {
+		case phaseIdle:
+			log.Printf("Idle timeout of connection from %s", conn.RemoteAddr())
+			metrics.idleTimeouts.Add(1)
+		case phaseHeaders:
+			log.Printf("Timeout reading request headers from %s", conn.RemoteAddr())
+			metrics.headerReadTimeouts.Add(1)
+		case phaseBody:
+			log.Printf("Timeout reading request body from %s", conn.RemoteAddr())
+			metrics.bodyReadTimeouts.Add(1)
+		}
+	}
+	return n, err
+}
+
+func (conn *TimeoutConn) Write(p []byte) (int, error) {
+	conn.SetWriteDeadline(time.Now().Add(*conn.server.config.writeTimeout))
+	n, err := conn.Conn.Write(p)
+	conn.checkWriteError(err)
+	return n, err
+}
+
+// ReadFrom keeps zero-copy sending of files by the underlying connection,
+// but splits it into segments, so that write timeout bounds each of them
+// rather than the whole file.
+func (conn *TimeoutConn) ReadFrom(reader io.Reader) (int64, error) {
+	limited, ok := reader.(*io.LimitedReader)
+	if !ok {
+		limited = &io.LimitedReader{R: reader, N: 1<<63 - 1}
+	}
+	if _, isFile := limited.R.(*os.File); !isFile {
+		// Hides ReadFrom, so that io.Copy doesn't call it again
+		return io.Copy(struct{ io.Writer }{conn}, reader)
+	}
+
+	var written int64
+	for limited.N > 0 {
+		conn.SetWriteDeadline(time.Now().Add(*conn.server.config.writeTimeout))
+		segment := &io.LimitedReader{R: limited.R, N: min(limited.N, sendFileSegmentSize)}
+		n, err := io.Copy(conn.Conn, segment)
+		written += n
+		limited.N -= n
+		if err != nil {
+			conn.checkWriteError(err)
+			return written, err
+		}
+		if segment.N > 0 {
+			// File has ended
+			break
+		}
+	}
+	return written, nil
+}
+
+func (conn *TimeoutConn) checkWriteError(err error) {
+	if isTimeoutError(err) {
+		log.Printf("Timeout writing response to %s", conn.RemoteAddr())
+		conn.server.metrics.writeTimeouts.Add(1)
+	}
+}
+
+func isTimeoutError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,88 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestChunkedUpload(t *testing.T) {
+	t.Run("Chunked upload is decoded before saving", func(t *testing.T) {
+		filename := "test-chunked-upload.txt"
+		t.Cleanup(func() {
+			os.Remove(path.Join(Config.Directory, filename))
+		})
+
+		content := strings.Repeat("chunked data ", 10000)
+		// Hiding length behind a plain reader forces the client to send body chunked
+		req, err := NewFileRequestWithMethod("POST", filename, io.MultiReader(strings.NewReader(content)))
+		if err != nil {
+			t.Fatalf("Failed to create upload request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to upload file: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got: %d", resp.StatusCode)
+		}
+
+		saved, err := os.ReadFile(path.Join(Config.Directory, filename))
+		if err != nil {
+			t.Fatalf("Failed to read uploaded file: %v", err)
+		}
+		if string(saved) != content {
+			t.Errorf("Expected file of %d bytes, got %d bytes", len(content), len(saved))
+		}
+	})
+
+	t.Run("Chunk extensions and trailers are not saved into file", func(t *testing.T) {
+		filename := "test-chunked-trailers.txt"
+		t.Cleanup(func() {
+			os.Remove(path.Join(Config.Directory, filename))
+		})
+
+		conn := DialServer(t)
+		defer conn.Close()
+
+		fmt.Fprintf(conn, "POST /files/%s HTTP/1.1\r\nHost: localhost\r\nTransfer-Encoding: chunked\r\n\r\n"+
+			"5;name=value\r\nHello\r\n8\r\n, World!\r\n0\r\nX-Checksum: 42\r\n\r\n", filename)
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got: %d", resp.StatusCode)
+		}
+
+		saved, err := os.ReadFile(path.Join(Config.Directory, filename))
+		if err != nil {
+			t.Fatalf("Failed to read uploaded file: %v", err)
+		}
+		if string(saved) != "Hello, World!" {
+			t.Errorf("Expected content 'Hello, World!', got: '%s'", string(saved))
+		}
+	})
+
+	t.Run("Content-Length together with Transfer-Encoding returns 400", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+
+		fmt.Fprint(conn, "POST /files/test-smuggling.txt HTTP/1.1\r\nHost: localhost\r\n"+
+			"Content-Length: 5\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n")
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got: %d", resp.StatusCode)
+		}
+		if _, err := os.Stat(path.Join(Config.Directory, "test-smuggling.txt")); !os.IsNotExist(err) {
+			t.Errorf("Expected file not to be created")
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package e2e
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func ExecuteGzipRequest(t *testing.T, url string) (*http.Response, string) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	// Setting header explicitly disables transparent decompression of client
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := ExecuteRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected gzip content encoding, got: '%s'", resp.Header.Get("Content-Encoding"))
+	}
+
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader: %v", err)
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to decompress response body: %v", err)
+	}
+
+	return resp, string(content)
+}
+
+func TestCompression(t *testing.T) {
+	t.Run("Small body is compressed in memory and sent with Content-Length", func(t *testing.T) {
+		expected := strings.Repeat("compressed", 100)
+		resp, content := ExecuteGzipRequest(t, Config.GetServerURL("/echo/"+expected))
+
+		if content != expected {
+			t.Errorf("Expected content '%s', got: '%s'", expected, content)
+		}
+		if resp.ContentLength <= 0 {
+			t.Errorf("Expected Content-Length to be set, got: %d", resp.ContentLength)
+		}
+	})
+
+	t.Run("Big file is compressed as stream and sent chunked", func(t *testing.T) {
+		filename := "test-big-file.txt"
+		expected := strings.Repeat("streamed compression ", 50000)
+		if err := os.WriteFile(path.Join(Config.Directory, filename), []byte(expected), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		t.Cleanup(func() {
+			os.Remove(path.Join(Config.Directory, filename))
+		})
+
+		resp, content := ExecuteGzipRequest(t, Config.GetServerURL("/files/"+filename))
+
+		if content != expected {
+			t.Errorf("Expected content of %d bytes, got %d bytes", len(expected), len(content))
+		}
+		if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" {
+			t.Errorf("Expected chunked transfer encoding, got: %v", resp.TransferEncoding)
+		}
+	})
+}
+
+func TestCompressionPolicy(t *testing.T) {
+	uncompressedCases := map[string]string{
+		"Tiny body is not compressed":               "/echo/tiny",
+		"Already compressed file is not compressed": "/files/test-archive.gz",
+	}
+
+	archivePath := path.Join(Config.Directory, "test-archive.gz")
+	if err := os.WriteFile(archivePath, []byte(strings.Repeat("not really gzip ", 1000)), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Remove(archivePath)
+	})
+
+	for name, url := range uncompressedCases {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", Config.GetServerURL(url), nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Accept-Encoding", "gzip")
+
+			resp, err := ExecuteRequest(req)
+			if err != nil {
+				t.Fatalf("Failed to execute request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Expected status 200, got: %d", resp.StatusCode)
+			}
+			if encoding := resp.Header.Get("Content-Encoding"); encoding != "" {
+				t.Errorf("Expected no Content-Encoding, got: '%s'", encoding)
+			}
+		})
+	}
+}
+
+func TestContentEncodingNegotiation(t *testing.T) {
+	cases := []struct {
+		acceptEncoding   string
+		expectedStatus   int
+		expectedEncoding string
+	}{
+		{"gzip", http.StatusOK, "gzip"},
+		{"gzip;q=0", http.StatusOK, ""},
+		{"gzip;q=0.5, deflate", http.StatusOK, "deflate"},
+		{"deflate;q=0.2, gzip; q=0.8", http.StatusOK, "gzip"},
+		{"gzip;q=0.1, identity", http.StatusOK, ""},
+		{"gzip, identity;q=0.5", http.StatusOK, "gzip"},
+		{"br", http.StatusOK, ""},
+		{"*", http.StatusOK, "gzip"},
+		{"*;q=0, deflate", http.StatusOK, "deflate"},
+		{"identity;q=0", http.StatusNotAcceptable, ""},
+		{"br, *;q=0", http.StatusNotAcceptable, ""},
+	}
+
+	for _, testCase := range cases {
+		t.Run("Accept-Encoding: "+testCase.acceptEncoding, func(t *testing.T) {
+			req, err := http.NewRequest("GET", Config.GetServerURL("/echo/"+strings.Repeat("negotiated", 100)), nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Accept-Encoding", testCase.acceptEncoding)
+
+			resp, err := ExecuteRequest(req)
+			if err != nil {
+				t.Fatalf("Failed to execute request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Expected status %d, got: %d", testCase.expectedStatus, resp.StatusCode)
+			}
+			if encoding := resp.Header.Get("Content-Encoding"); encoding != testCase.expectedEncoding {
+				t.Errorf("Expected Content-Encoding '%s', got: '%s'", testCase.expectedEncoding, encoding)
+			}
+			if vary := resp.Header.Get("Vary"); vary != "Accept-Encoding" {
+				t.Errorf("Expected 'Vary: Accept-Encoding', got: '%s'", vary)
+			}
+		})
+	}
+}
+
+func TestIdentityRefused(t *testing.T) {
+	cases := []struct {
+		name             string
+		target           string
+		acceptEncoding   string
+		expectedStatus   int
+		expectedEncoding string
+	}{
+		{"Small body is compressed anyway", "/echo/hi", "gzip, identity;q=0", http.StatusOK, "gzip"},
+		{"Small body without acceptable coding returns 406", "/echo/hi", "br, identity;q=0", http.StatusNotAcceptable, ""},
+		{"Missing file keeps 404", "/files/non_existant_file", "identity;q=0", http.StatusNotFound, ""},
+		{"Unknown path keeps 404", "/nope", "*;q=0", http.StatusNotFound, ""},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", Config.GetServerURL(testCase.target), nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Accept-Encoding", testCase.acceptEncoding)
+
+			resp, err := ExecuteRequest(req)
+			if err != nil {
+				t.Fatalf("Failed to execute request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Expected status %d, got: %d", testCase.expectedStatus, resp.StatusCode)
+			}
+			if encoding := resp.Header.Get("Content-Encoding"); encoding != testCase.expectedEncoding {
+				t.Errorf("Expected Content-Encoding '%s', got: '%s'", testCase.expectedEncoding, encoding)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func ExecuteConditionalRequest(t *testing.T, headers map[string]string) *http.Response {
+	req, err := NewFileRequest("foo")
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := ExecuteRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	resp.Body.Close()
+
+	return resp
+}
+
+func TestConditionalGet(t *testing.T) {
+	initial := ExecuteConditionalRequest(t, nil)
+	etag := initial.Header.Get("ETag")
+	lastModified := initial.Header.Get("Last-Modified")
+
+	if etag == "" || lastModified == "" {
+		t.Fatalf("Expected ETag and Last-Modified headers, got: '%s' and '%s'", etag, lastModified)
+	}
+
+	past := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	future := time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat)
+
+	cases := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{"If-None-Match with current ETag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"If-None-Match with weak current ETag", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"If-None-Match with other ETag", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"If-None-Match with any ETag", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"If-Modified-Since with Last-Modified", map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified},
+		{"If-Modified-Since in the past", map[string]string{"If-Modified-Since": past}, http.StatusOK},
+		{"If-None-Match takes precedence over If-Modified-Since",
+			map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": future}, http.StatusOK},
+		{"If-Match with current ETag", map[string]string{"If-Match": etag}, http.StatusOK},
+		{"If-Match with other ETag", map[string]string{"If-Match": `"other", "another"`}, http.StatusPreconditionFailed},
+		{"If-Unmodified-Since in the past", map[string]string{"If-Unmodified-Since": past}, http.StatusPreconditionFailed},
+		{"If-Unmodified-Since in the future", map[string]string{"If-Unmodified-Since": future}, http.StatusOK},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resp := ExecuteConditionalRequest(t, testCase.headers)
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Expected status %d, got: %d", testCase.expectedStatus, resp.StatusCode)
+			}
+			if resp.StatusCode == http.StatusNotModified && resp.Header.Get("ETag") != etag {
+				t.Errorf("Expected 304 response to carry ETag '%s', got: '%s'", etag, resp.Header.Get("ETag"))
+			}
+		})
+	}
+
+	t.Run("If-Range with current ETag returns range", func(t *testing.T) {
+		resp := ExecuteConditionalRequest(t, map[string]string{"Range": "bytes=0-4", "If-Range": etag})
+
+		if resp.StatusCode != http.StatusPartialContent {
+			t.Errorf("Expected status 206, got: %d", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package e2e
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestContentType(t *testing.T) {
+	cases := []struct {
+		name         string
+		filename     string
+		content      string
+		expectedType string
+	}{
+		{"HTML by extension", "test-page.html", "<p>Hi</p>", "text/html; charset=utf-8"},
+		{"CSS by extension", "test-style.css", "p { color: red; }", "text/css; charset=utf-8"},
+		{"JavaScript by extension", "test-script.js", "console.log(1)", "text/javascript; charset=utf-8"},
+		{"PNG by extension", "test-image.png", "\x89PNG\r\n\x1a\n", "image/png"},
+		{"HTML sniffed from content", "test-page", "<!DOCTYPE html><html></html>", "text/html; charset=utf-8"},
+		{"PNG sniffed from content", "test-image", "\x89PNG\r\n\x1a\n\x00\x00", "image/png"},
+		{"Binary sniffed from content", "test-binary", "\x00\x01\x02\x03", "application/octet-stream"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			filePath := path.Join(Config.Directory, testCase.filename)
+			if err := os.WriteFile(filePath, []byte(testCase.content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			t.Cleanup(func() {
+				os.Remove(filePath)
+			})
+
+			req, err := NewFileRequest(testCase.filename)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			resp, err := ExecuteRequest(req)
+			if err != nil {
+				t.Fatalf("Failed to execute request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Expected status 200, got: %d", resp.StatusCode)
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != testCase.expectedType {
+				t.Errorf("Expected Content-Type '%s', got: '%s'", testCase.expectedType, contentType)
+			}
+		})
+	}
+
+	t.Run("Text responses are plain text in UTF-8", func(t *testing.T) {
+		req, err := http.NewRequest("GET", Config.GetServerURL("/echo/abc"), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		resp.Body.Close()
+
+		if contentType := resp.Header.Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+			t.Errorf("Expected Content-Type 'text/plain; charset=utf-8', got: '%s'", contentType)
+		}
+	})
+}
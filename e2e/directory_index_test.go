@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+type indexEntry struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	Type string `json:"type"`
+}
+
+type indexPage struct {
+	Page    int          `json:"page"`
+	PerPage int          `json:"per_page"`
+	Total   int          `json:"total"`
+	Entries []indexEntry `json:"entries"`
+}
+
+func executeIndexRequest(t *testing.T, query string, accept string) (*http.Response, string) {
+	req, err := http.NewRequest("GET", Config.GetServerURL("/files/"+query), nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := ExecuteRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return resp, string(body)
+}
+
+func TestDirectoryIndex(t *testing.T) {
+	files := map[string]string{
+		"index-small.txt": "a",
+		"index-large.txt": strings.Repeat("a", 100000),
+		".index-hidden":   "secret",
+	}
+	for name, content := range files {
+		filePath := path.Join(Config.Directory, name)
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		t.Cleanup(func() {
+			os.Remove(filePath)
+		})
+	}
+
+	t.Run("Plain text listing by default", func(t *testing.T) {
+		resp, body := executeIndexRequest(t, "", "")
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+			t.Errorf("Expected plain text, got: '%s'", contentType)
+		}
+		if !strings.Contains(body, "index-small.txt\t1\t") {
+			t.Errorf("Expected listing to contain index-small.txt, got: '%s'", body)
+		}
+		if strings.Contains(body, ".index-hidden") {
+			t.Errorf("Expected dotfiles to be hidden, got: '%s'", body)
+		}
+	})
+
+	t.Run("HTML listing", func(t *testing.T) {
+		resp, body := executeIndexRequest(t, "", "text/html")
+
+		if contentType := resp.Header.Get("Content-Type"); contentType != "text/html; charset=utf-8" {
+			t.Errorf("Expected HTML, got: '%s'", contentType)
+		}
+		if !strings.Contains(body, `<a href="/files/index-large.txt">index-large.txt</a>`) {
+			t.Errorf("Expected link to index-large.txt, got: '%s'", body)
+		}
+		if !strings.Contains(resp.Header.Get("Vary"), "Accept") {
+			t.Errorf("Expected Vary to contain Accept, got: '%s'", resp.Header.Get("Vary"))
+		}
+	})
+
+	t.Run("JSON listing sorted by size", func(t *testing.T) {
+		resp, body := executeIndexRequest(t, "?sort=size&order=desc&per_page=1", "application/json")
+
+		if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("Expected JSON, got: '%s'", contentType)
+		}
+
+		var page indexPage
+		if err := json.Unmarshal([]byte(body), &page); err != nil {
+			t.Fatalf("Failed to decode listing: %v", err)
+		}
+		if page.Page != 1 || page.PerPage != 1 || len(page.Entries) != 1 {
+			t.Fatalf("Expected the first page with one entry, got: %+v", page)
+		}
+		if page.Total < 2 {
+			t.Errorf("Expected total of at least 2 entries, got: %d", page.Total)
+		}
+		if entry := page.Entries[0]; entry.Name != "index-large.txt" || entry.Size != 100000 || entry.Type != "file" {
+			t.Errorf("Expected the largest file first, got: %+v", entry)
+		}
+	})
+
+	t.Run("Page past the end is empty", func(t *testing.T) {
+		_, body := executeIndexRequest(t, "?page=1000", "application/json")
+
+		var page indexPage
+		if err := json.Unmarshal([]byte(body), &page); err != nil {
+			t.Fatalf("Failed to decode listing: %v", err)
+		}
+		if len(page.Entries) != 0 {
+			t.Errorf("Expected no entries, got: %+v", page.Entries)
+		}
+	})
+
+	t.Run("Invalid query parameters", func(t *testing.T) {
+		for _, query := range []string{"?sort=color", "?order=up", "?page=0", "?per_page=abc", "?per_page=100000"} {
+			resp, _ := executeIndexRequest(t, query, "")
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for '%s', got: %d", query, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("Unacceptable media type", func(t *testing.T) {
+		resp, _ := executeIndexRequest(t, "", "image/png")
+		if resp.StatusCode != http.StatusNotAcceptable {
+			t.Errorf("Expected status 406, got: %d", resp.StatusCode)
+		}
+	})
+}
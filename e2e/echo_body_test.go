@@ -0,0 +1,77 @@
+package e2e
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func executeEchoBodyRequest(t *testing.T, contentType string, body string) (*http.Response, string) {
+	req, err := http.NewRequest("POST", Config.GetServerURL("/echo"), strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := ExecuteRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return resp, string(content)
+}
+
+func TestEchoBody(t *testing.T) {
+	t.Run("Url-encoded form is decoded", func(t *testing.T) {
+		resp, body := executeEchoBodyRequest(t, "application/x-www-form-urlencoded", "name=a+b&tag=1&tag=%32")
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("Expected Content-Type 'application/json', got: '%s'", contentType)
+		}
+		if expected := `{"name":["a b"],"tag":["1","2"]}`; body != expected {
+			t.Errorf("Expected body '%s', got: '%s'", expected, body)
+		}
+	})
+
+	t.Run("JSON body is decoded", func(t *testing.T) {
+		resp, body := executeEchoBodyRequest(t, "application/json; charset=utf-8", `{ "list": [1, 2], "ok": true }`)
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+		if expected := `{"list":[1,2],"ok":true}`; body != expected {
+			t.Errorf("Expected body '%s', got: '%s'", expected, body)
+		}
+	})
+
+	cases := []struct {
+		name         string
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		{"Unsupported Content-Type returns 415", "text/plain", "hello", http.StatusUnsupportedMediaType},
+		{"Missing Content-Type returns 415", "", "hello", http.StatusUnsupportedMediaType},
+		{"Malformed JSON returns 400", "application/json", `{"a":`, http.StatusBadRequest},
+		{"Several JSON values return 400", "application/json", `{} {}`, http.StatusBadRequest},
+		{"Too large body returns 413", "application/json", `"` + strings.Repeat("a", 70000) + `"`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resp, _ := executeEchoBodyRequest(t, testCase.contentType, testCase.body)
+			if resp.StatusCode != testCase.expectedCode {
+				t.Errorf("Expected status %d, got: %d", testCase.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+}
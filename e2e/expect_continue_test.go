@@ -0,0 +1,69 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestExpectContinue(t *testing.T) {
+	t.Run("100 Continue is sent before body is read", func(t *testing.T) {
+		filename := "test-expect-continue.txt"
+		t.Cleanup(func() {
+			os.Remove(path.Join(Config.Directory, filename))
+		})
+
+		conn := DialServer(t)
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		fmt.Fprintf(conn, "POST /files/%s HTTP/1.1\r\nHost: localhost\r\nContent-Length: 5\r\nExpect: 100-continue\r\n\r\n", filename)
+
+		resp, _ := ReadRawResponse(t, reader)
+		if resp.StatusCode != http.StatusContinue {
+			t.Fatalf("Expected status 100, got: %d", resp.StatusCode)
+		}
+
+		fmt.Fprint(conn, "hello")
+
+		resp, _ = ReadRawResponse(t, reader)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got: %d", resp.StatusCode)
+		}
+
+		saved, err := os.ReadFile(path.Join(Config.Directory, filename))
+		if err != nil || string(saved) != "hello" {
+			t.Errorf("Expected file content 'hello', got: '%s' (%v)", saved, err)
+		}
+	})
+
+	t.Run("Request rejected before reading body gets final status only", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+
+		fmt.Fprint(conn, "POST /files/foo HTTP/1.1\r\nHost: localhost\r\nContent-Length: 5\r\nExpect: 100-continue\r\n\r\n")
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusConflict {
+			t.Errorf("Expected status 409, got: %d", resp.StatusCode)
+		}
+		if !resp.Close {
+			t.Errorf("Expected connection to be closed")
+		}
+	})
+
+	t.Run("Unknown expectation returns 417", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+
+		fmt.Fprint(conn, "POST /echo HTTP/1.1\r\nHost: localhost\r\nContent-Length: 0\r\nExpect: something-else\r\n\r\n")
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusExpectationFailed {
+			t.Errorf("Expected status 417, got: %d", resp.StatusCode)
+		}
+	})
+}
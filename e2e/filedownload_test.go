@@ -64,8 +64,8 @@ func TestFiles(t *testing.T) {
 
 		// Check Content-Type header
 		contentType := resp.Header.Get("Content-Type")
-		if contentType != "application/octet-stream" {
-			t.Errorf("Expected Content-Type 'application/octet-stream', got: '%s'", contentType)
+		if contentType != "text/plain; charset=utf-8" {
+			t.Errorf("Expected Content-Type 'text/plain; charset=utf-8', got: '%s'", contentType)
 		}
 
 		// Read response body into memory
@@ -143,8 +143,8 @@ func TestFiles(t *testing.T) {
 
 		// Check Content-Type header
 		contentType := resp.Header.Get("Content-Type")
-		if contentType != "application/octet-stream" {
-			t.Errorf("Expected Content-Type 'application/octet-stream', got: '%s'", contentType)
+		if contentType != "text/plain; charset=utf-8" {
+			t.Errorf("Expected Content-Type 'text/plain; charset=utf-8', got: '%s'", contentType)
 		}
 
 		// Read and verify content
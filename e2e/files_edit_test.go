@@ -0,0 +1,250 @@
+package e2e
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func executeEditRequest(t *testing.T, method string, filename string, body string, headers map[string]string) *http.Response {
+	req, err := NewFileRequestWithMethod(method, filename, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := ExecuteRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func readTestFile(t *testing.T, filename string) string {
+	content, err := os.ReadFile(path.Join(Config.Directory, filename))
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	return string(content)
+}
+
+func TestFilesEdit(t *testing.T) {
+	filename := "test-edit.txt"
+	t.Cleanup(func() {
+		os.Remove(path.Join(Config.Directory, filename))
+	})
+
+	t.Run("PUT creates file", func(t *testing.T) {
+		resp := executeEditRequest(t, "PUT", filename, "hello", nil)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got: %d", resp.StatusCode)
+		}
+		if resp.Header.Get("ETag") == "" {
+			t.Errorf("Expected ETag of created file")
+		}
+		if content := readTestFile(t, filename); content != "hello" {
+			t.Errorf("Expected content 'hello', got: '%s'", content)
+		}
+	})
+
+	t.Run("PUT replaces file", func(t *testing.T) {
+		resp := executeEditRequest(t, "PUT", filename, "replaced", nil)
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got: %d", resp.StatusCode)
+		}
+		if content := readTestFile(t, filename); content != "replaced" {
+			t.Errorf("Expected content 'replaced', got: '%s'", content)
+		}
+	})
+
+	t.Run("PATCH appends to file", func(t *testing.T) {
+		resp := executeEditRequest(t, "PATCH", filename, " content", nil)
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got: %d", resp.StatusCode)
+		}
+		if content := readTestFile(t, filename); content != "replaced content" {
+			t.Errorf("Expected content 'replaced content', got: '%s'", content)
+		}
+	})
+
+	t.Run("PATCH updates byte range", func(t *testing.T) {
+		resp := executeEditRequest(t, "PATCH", filename, "RE", map[string]string{"Content-Range": "bytes 0-1/*"})
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got: %d", resp.StatusCode)
+		}
+		if content := readTestFile(t, filename); content != "REplaced content" {
+			t.Errorf("Expected content 'REplaced content', got: '%s'", content)
+		}
+	})
+
+	t.Run("PATCH with range past the end returns 416", func(t *testing.T) {
+		resp := executeEditRequest(t, "PATCH", filename, "xx", map[string]string{"Content-Range": "bytes 100-101/*"})
+		if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+			t.Errorf("Expected status 416, got: %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("PATCH with range not matching body returns 400", func(t *testing.T) {
+		resp := executeEditRequest(t, "PATCH", filename, "xx", map[string]string{"Content-Range": "bytes 0-9/*"})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got: %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Chunked PATCH with body shorter than range leaves file unchanged", func(t *testing.T) {
+		// Hiding length behind a plain reader forces the client to send body chunked
+		req, err := NewFileRequestWithMethod("PATCH", filename, io.MultiReader(strings.NewReader("AAA")))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Range", "bytes 0-4/*")
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got: %d", resp.StatusCode)
+		}
+		if content := readTestFile(t, filename); content != "REplaced content" {
+			t.Errorf("Expected content to be kept, got: '%s'", content)
+		}
+	})
+
+	t.Run("If-Match with stale ETag returns 412", func(t *testing.T) {
+		resp := executeEditRequest(t, "PUT", filename, "stale", map[string]string{"If-Match": `"stale"`})
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("Expected status 412, got: %d", resp.StatusCode)
+		}
+		if content := readTestFile(t, filename); content != "REplaced content" {
+			t.Errorf("Expected content to be kept, got: '%s'", content)
+		}
+	})
+
+	t.Run("If-Match with current ETag succeeds", func(t *testing.T) {
+		req, err := NewFileRequestWithMethod("HEAD", filename, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		head, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		io.Copy(io.Discard, head.Body)
+		head.Body.Close()
+
+		resp := executeEditRequest(t, "PATCH", filename, "!", map[string]string{"If-Match": head.Header.Get("ETag")})
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("Expected status 204, got: %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("If-None-Match star prevents overwrite", func(t *testing.T) {
+		resp := executeEditRequest(t, "PUT", filename, "new", map[string]string{"If-None-Match": "*"})
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("Expected status 412, got: %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("DELETE removes file", func(t *testing.T) {
+		resp := executeEditRequest(t, "DELETE", filename, "", nil)
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got: %d", resp.StatusCode)
+		}
+		if _, err := os.Stat(path.Join(Config.Directory, filename)); !os.IsNotExist(err) {
+			t.Errorf("Expected file to be removed, got: %v", err)
+		}
+	})
+
+	t.Run("DELETE and PATCH of missing file return 404", func(t *testing.T) {
+		for _, method := range []string{"DELETE", "PATCH"} {
+			resp := executeEditRequest(t, method, filename, "x", nil)
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("Expected status 404 for %s, got: %d", method, resp.StatusCode)
+			}
+		}
+	})
+}
+
+func TestConcurrentFileEdits(t *testing.T) {
+	filename := "test-concurrent-edit.txt"
+	filePath := path.Join(Config.Directory, filename)
+	t.Cleanup(func() {
+		os.Remove(filePath)
+	})
+
+	const editors = 10
+	// Bodies are big enough for uploads to overlap
+	content := strings.Repeat("x", 256*1024)
+
+	// sendConcurrently sends requests of all editors at once and counts
+	// statuses of responses
+	sendConcurrently := func(t *testing.T, method string, headers map[string]string) map[int]int {
+		statuses := make(chan int, editors)
+		for range editors {
+			go func() {
+				req, err := NewFileRequestWithMethod(method, filename, strings.NewReader(content))
+				if err != nil {
+					statuses <- 0
+					return
+				}
+				for name, value := range headers {
+					req.Header.Set(name, value)
+				}
+				resp, err := ExecuteRequest(req)
+				if err != nil {
+					t.Errorf("Failed to execute request: %v", err)
+					statuses <- 0
+					return
+				}
+				resp.Body.Close()
+				statuses <- resp.StatusCode
+			}()
+		}
+
+		counts := map[int]int{}
+		for range editors {
+			counts[<-statuses]++
+		}
+		return counts
+	}
+
+	t.Run("Only one of concurrent creations succeeds", func(t *testing.T) {
+		counts := sendConcurrently(t, "PUT", map[string]string{"If-None-Match": "*"})
+		if counts[http.StatusCreated] != 1 || counts[http.StatusPreconditionFailed] != editors-1 {
+			t.Errorf("Expected one 201 and %d times 412, got: %v", editors-1, counts)
+		}
+	})
+
+	t.Run("Only one of concurrent conditional replacements succeeds", func(t *testing.T) {
+		head := executeEditRequest(t, "HEAD", filename, "", nil)
+
+		counts := sendConcurrently(t, "PUT", map[string]string{"If-Match": head.Header.Get("ETag")})
+		if counts[http.StatusNoContent] != 1 || counts[http.StatusPreconditionFailed] != editors-1 {
+			t.Errorf("Expected one 204 and %d times 412, got: %v", editors-1, counts)
+		}
+	})
+
+	t.Run("Concurrent appends are all kept", func(t *testing.T) {
+		counts := sendConcurrently(t, "PATCH", nil)
+		if counts[http.StatusNoContent] != editors {
+			t.Errorf("Expected %d times 204, got: %v", editors, counts)
+		}
+
+		info, err := os.Stat(filePath)
+		if err != nil {
+			t.Fatalf("Failed to stat file: %v", err)
+		}
+		if expected := int64((editors + 1) * len(content)); info.Size() != expected {
+			t.Errorf("Expected file of %d bytes, got: %d", expected, info.Size())
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+)
+
+type formFile struct {
+	field    string
+	filename string
+	content  string
+}
+
+func executeFormUpload(t *testing.T, target string, fields map[string]string, files []formFile) (*http.Response, []byte) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	for _, file := range files {
+		part, err := writer.CreateFormFile(file.field, file.filename)
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		part.Write([]byte(file.content))
+	}
+	writer.Close()
+
+	req, err := http.NewRequest("POST", Config.GetServerURL(target), &body)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := ExecuteRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var content bytes.Buffer
+	content.ReadFrom(resp.Body)
+	return resp, content.Bytes()
+}
+
+func TestFormUpload(t *testing.T) {
+	t.Cleanup(func() {
+		for _, filename := range []string{"test-form-a.txt", "test-form-b.txt"} {
+			os.Remove(path.Join(Config.Directory, filename))
+		}
+	})
+
+	t.Run("Files of form are stored under their names", func(t *testing.T) {
+		resp, body := executeFormUpload(t, "/files/", map[string]string{"note": "hello"}, []formFile{
+			{"first", "test-form-a.txt", "content of a"},
+			{"second", "test-form-b.txt", "content of b"},
+		})
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got: %d '%s'", resp.StatusCode, body)
+		}
+		if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("Expected JSON summary, got: '%s'", contentType)
+		}
+
+		var summary struct {
+			Files []struct {
+				Field string `json:"field"`
+				Name  string `json:"name"`
+				Path  string `json:"path"`
+				Size  int64  `json:"size"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(body, &summary); err != nil {
+			t.Fatalf("Failed to decode summary: %v", err)
+		}
+		if len(summary.Files) != 2 {
+			t.Fatalf("Expected 2 stored files, got: %+v", summary.Files)
+		}
+		if file := summary.Files[0]; file.Field != "first" || file.Name != "test-form-a.txt" ||
+			file.Path != "/files/test-form-a.txt" || file.Size != 12 {
+			t.Errorf("Unexpected summary of the first file: %+v", file)
+		}
+
+		saved, err := os.ReadFile(path.Join(Config.Directory, "test-form-b.txt"))
+		if err != nil || string(saved) != "content of b" {
+			t.Errorf("Expected file content 'content of b', got: '%s' (%v)", saved, err)
+		}
+	})
+
+	t.Run("Existing file returns 409", func(t *testing.T) {
+		resp, _ := executeFormUpload(t, "/files/", nil, []formFile{{"file", "test-form-a.txt", "again"}})
+		if resp.StatusCode != http.StatusConflict {
+			t.Errorf("Expected status 409, got: %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Invalid file name returns 400", func(t *testing.T) {
+		resp, _ := executeFormUpload(t, "/files/", nil, []formFile{{"file", ".hidden", "secret"}})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got: %d", resp.StatusCode)
+		}
+		if _, err := os.Stat(path.Join(Config.Directory, ".hidden")); !os.IsNotExist(err) {
+			t.Errorf("Expected file not to be saved")
+		}
+	})
+
+	t.Run("Form without files returns 400", func(t *testing.T) {
+		resp, _ := executeFormUpload(t, "/files/", map[string]string{"note": "hello"}, nil)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got: %d", resp.StatusCode)
+		}
+	})
+}
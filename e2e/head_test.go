@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func ExecuteHeadRequest(t *testing.T, url string, acceptEncoding string) *http.Response {
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	resp, err := ExecuteRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	resp.Body.Close()
+
+	return resp
+}
+
+func TestHead(t *testing.T) {
+	t.Run("HEAD of file returns headers of GET", func(t *testing.T) {
+		resp := ExecuteHeadRequest(t, Config.GetServerURL("/files/foo"), "identity")
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got: %d", resp.StatusCode)
+		}
+		if resp.ContentLength != 13 {
+			t.Errorf("Expected Content-Length 13, got: %d", resp.ContentLength)
+		}
+		if contentType := resp.Header.Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+			t.Errorf("Expected Content-Type 'text/plain; charset=utf-8', got: '%s'", contentType)
+		}
+	})
+
+	t.Run("HEAD of missing file returns 404", func(t *testing.T) {
+		resp := ExecuteHeadRequest(t, Config.GetServerURL("/files/non_existant_file"), "identity")
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404, got: %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("HEAD of small file follows compression policy", func(t *testing.T) {
+		resp := ExecuteHeadRequest(t, Config.GetServerURL("/files/foo"), "gzip")
+
+		// Body of foo is below minimal size of compression
+		if encoding := resp.Header.Get("Content-Encoding"); encoding != "" {
+			t.Errorf("Expected no Content-Encoding, got: '%s'", encoding)
+		}
+		if vary := resp.Header.Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("Expected 'Vary: Accept-Encoding', got: '%s'", vary)
+		}
+	})
+	t.Run("HEAD of compressible file returns headers of GET", func(t *testing.T) {
+		filePath := path.Join(Config.Directory, "head_compressible.txt")
+		if err := os.WriteFile(filePath, []byte(strings.Repeat("compressible line of text\n", 200)), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		t.Cleanup(func() {
+			os.Remove(filePath)
+		})
+
+		url := Config.GetServerURL("/files/head_compressible.txt")
+		get, _ := ExecuteGzipRequest(t, url)
+		head := ExecuteHeadRequest(t, url, "gzip")
+
+		if head.ContentLength <= 0 || head.ContentLength != get.ContentLength {
+			t.Errorf("Expected Content-Length %d of GET, got: %d", get.ContentLength, head.ContentLength)
+		}
+		for _, name := range []string{"Content-Encoding", "Content-Type", "ETag", "Vary"} {
+			if head.Header.Get(name) != get.Header.Get(name) {
+				t.Errorf("Expected %s '%s' of GET, got: '%s'", name, get.Header.Get(name), head.Header.Get(name))
+			}
+		}
+	})
+
+	t.Run("HEAD of large compressible file claims no framing", func(t *testing.T) {
+		filePath := path.Join(Config.Directory, "head_large_compressible.txt")
+		// Bigger than compression buffer, so compressed length isn't known
+		if err := os.WriteFile(filePath, []byte(strings.Repeat("compressible line of text\n", 4000)), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		t.Cleanup(func() {
+			os.Remove(filePath)
+		})
+
+		conn := DialServer(t)
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		fmt.Fprint(conn, "HEAD /files/head_large_compressible.txt HTTP/1.0\r\nAccept-Encoding: gzip\r\nConnection: keep-alive\r\n\r\n")
+
+		resp, err := http.ReadResponse(reader, &http.Request{Method: "HEAD"})
+		if err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		resp.Body.Close()
+
+		if encoding := resp.Header.Get("Content-Encoding"); encoding != "gzip" {
+			t.Errorf("Expected Content-Encoding 'gzip', got: '%s'", encoding)
+		}
+		if resp.ContentLength != -1 || len(resp.TransferEncoding) != 0 {
+			t.Errorf("Expected neither Content-Length nor Transfer-Encoding, got: %d %v", resp.ContentLength, resp.TransferEncoding)
+		}
+		if connection := resp.Header.Get("Connection"); connection != "keep-alive" {
+			t.Errorf("Expected 'Connection: keep-alive', got: '%s'", connection)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHeaders(t *testing.T) {
+	t.Run("Header value containing colons is read completely", func(t *testing.T) {
+		req, err := http.NewRequest("GET", Config.GetServerURL("/user-agent"), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("User-Agent", "agent: http://localhost:4221/")
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != "agent: http://localhost:4221/" {
+			t.Errorf("Expected full user agent, got: '%s'", string(body))
+		}
+	})
+
+	t.Run("Repeated Accept-Encoding headers are combined", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+
+		fmt.Fprintf(conn, "GET /echo/%s HTTP/1.1\r\nHost: localhost\r\n"+
+			"Accept-Encoding: br\r\nAccept-Encoding: gzip\r\n\r\n", strings.Repeat("a", 1000))
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if encoding := resp.Header.Get("Content-Encoding"); encoding != "gzip" {
+			t.Errorf("Expected Content-Encoding 'gzip', got: '%s'", encoding)
+		}
+	})
+
+	t.Run("Response header names are canonical", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+
+		fmt.Fprint(conn, "GET /echo/abc HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+
+		raw, err := io.ReadAll(conn)
+		if err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+
+		head, _, _ := strings.Cut(string(raw), "\r\n\r\n")
+		for _, expected := range []string{"\r\nContent-Type: ", "\r\nContent-Length: 3", "\r\nVary: Accept-Encoding"} {
+			if !strings.Contains(head, expected) {
+				t.Errorf("Expected response head to contain '%s', got:\n%s", strings.TrimSpace(expected), head)
+			}
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func DialServer(t *testing.T) net.Conn {
+	conn, err := net.Dial("tcp", net.JoinHostPort(Config.ServerHost, strconv.Itoa(Config.ServerPort)))
+	if err != nil {
+		t.Fatalf("Failed to connect to server: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func ReadRawResponse(t *testing.T, reader *bufio.Reader) (*http.Response, string) {
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+
+	return resp, string(body)
+}
+
+func TestKeepAlive(t *testing.T) {
+	t.Run("Several requests are served over the same connection", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		for _, word := range []string{"first", "second", "third"} {
+			fmt.Fprintf(conn, "GET /echo/%s HTTP/1.1\r\nHost: localhost\r\n\r\n", word)
+
+			resp, body := ReadRawResponse(t, reader)
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Expected status 200, got: %d", resp.StatusCode)
+			}
+			if body != word {
+				t.Errorf("Expected body '%s', got: '%s'", word, body)
+			}
+			if resp.Close {
+				t.Errorf("Expected connection to be kept alive after '%s'", word)
+			}
+		}
+	})
+
+	t.Run("Connection close header closes connection after response", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		fmt.Fprint(conn, "GET /echo/bye HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+
+		resp, body := ReadRawResponse(t, reader)
+
+		if body != "bye" {
+			t.Errorf("Expected body 'bye', got: '%s'", body)
+		}
+		if !resp.Close {
+			t.Errorf("Expected 'Connection: close' header in response")
+		}
+		if _, err := reader.ReadByte(); err != io.EOF {
+			t.Errorf("Expected server to close connection, got: %v", err)
+		}
+	})
+
+	t.Run("Body left unread by handler is skipped", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		fmt.Fprint(conn, "GET /echo/first HTTP/1.1\r\nHost: localhost\r\nContent-Length: 5\r\n\r\nhello")
+		fmt.Fprint(conn, "GET /echo/second HTTP/1.1\r\nHost: localhost\r\n\r\n")
+
+		for _, word := range []string{"first", "second"} {
+			resp, body := ReadRawResponse(t, reader)
+			if resp.StatusCode != http.StatusOK || body != word {
+				t.Errorf("Expected status 200 with '%s', got: %d '%s'", word, resp.StatusCode, body)
+			}
+		}
+	})
+
+	t.Run("Connection with large unread body is closed", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		const size = 1024 * 1024
+		fmt.Fprintf(conn, "GET /echo/big HTTP/1.1\r\nHost: localhost\r\nContent-Length: %d\r\n\r\n", size)
+		go conn.Write(make([]byte, size))
+
+		resp, body := ReadRawResponse(t, reader)
+		if resp.StatusCode != http.StatusOK || body != "big" {
+			t.Errorf("Expected status 200 with 'big', got: %d '%s'", resp.StatusCode, body)
+		}
+		if !resp.Close {
+			t.Errorf("Expected 'Connection: close' header in response")
+		}
+		if _, err := reader.ReadByte(); err == nil {
+			t.Errorf("Expected server to close connection")
+		}
+	})
+
+	t.Run("HTTP/1.0 connection is closed unless keep-alive is requested", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		fmt.Fprint(conn, "GET /echo/old HTTP/1.0\r\nConnection: keep-alive\r\n\r\n")
+
+		resp, _ := ReadRawResponse(t, reader)
+		if resp.Header.Get("Connection") != "keep-alive" {
+			t.Errorf("Expected 'Connection: keep-alive' header, got: '%s'", resp.Header.Get("Connection"))
+		}
+
+		fmt.Fprint(conn, "GET /echo/old HTTP/1.0\r\n\r\n")
+
+		ReadRawResponse(t, reader)
+		if _, err := reader.ReadByte(); err != io.EOF {
+			t.Errorf("Expected server to close connection, got: %v", err)
+		}
+	})
+}
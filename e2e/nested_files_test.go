@@ -0,0 +1,127 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestNestedFiles(t *testing.T) {
+	t.Cleanup(func() {
+		os.RemoveAll(path.Join(Config.Directory, "nested"))
+	})
+
+	upload := func(t *testing.T, target string, content string) int {
+		req, err := http.NewRequest("POST", Config.GetServerURL(target), strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("Upload without existing directory returns 409", func(t *testing.T) {
+		if code := upload(t, "/files/nested/a/file.txt", "abc"); code != http.StatusConflict {
+			t.Errorf("Expected status 409, got: %d", code)
+		}
+	})
+
+	t.Run("Upload creates directories when asked", func(t *testing.T) {
+		if code := upload(t, "/files/nested/a/file.txt?parents=true", "abc"); code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got: %d", code)
+		}
+
+		req, err := NewFileRequest("nested/a/file.txt")
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		content, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(content) != "abc" {
+			t.Errorf("Expected status 200 with 'abc', got: %d '%s'", resp.StatusCode, content)
+		}
+		if disposition := resp.Header.Get("Content-Disposition"); disposition != `inline; filename="file.txt"` {
+			t.Errorf("Expected file name in Content-Disposition, got: '%s'", disposition)
+		}
+	})
+
+	t.Run("Subdirectory is listed", func(t *testing.T) {
+		resp, body := executeIndexRequest(t, "nested/", "")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+		if !strings.Contains(body, "a\t") || !strings.Contains(body, "directory") {
+			t.Errorf("Expected listing to contain directory 'a', got: '%s'", body)
+		}
+	})
+
+	t.Run("Symlink leading outside of directory is forbidden", func(t *testing.T) {
+		linkPath := path.Join(Config.Directory, "nested", "outside")
+		if err := os.Symlink(os.TempDir(), linkPath); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+
+		outsideFile, err := os.CreateTemp("", "outside")
+		if err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		outsideFile.Close()
+		t.Cleanup(func() {
+			os.Remove(outsideFile.Name())
+		})
+
+		req, err := NewFileRequest("nested/outside/" + path.Base(outsideFile.Name()))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403, got: %d", resp.StatusCode)
+		}
+
+		if code := upload(t, "/files/nested/outside/new/file.txt?parents=true", "abc"); code != http.StatusForbidden {
+			t.Errorf("Expected status 403 for upload, got: %d", code)
+		}
+	})
+
+	invalidTargets := []string{
+		"/files/nested/../foo",
+		"/files/nested/%2E%2E/foo",
+		"/files//etc/passwd",
+		"/files/nested/.hidden",
+		"/files/nested/a%2Ffile.txt",
+		"/files/nested%2Fa/",
+	}
+
+	for _, target := range invalidTargets {
+		t.Run(fmt.Sprintf("Path '%s' returns 400", target), func(t *testing.T) {
+			conn := DialServer(t)
+			defer conn.Close()
+
+			fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: localhost\r\n\r\n", target)
+
+			resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got: %d", resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestRequestTarget(t *testing.T) {
+	t.Run("Echo returns percent-decoded path", func(t *testing.T) {
+		req, err := http.NewRequest("GET", Config.GetServerURL("/echo/hello%20world"), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != "hello world" {
+			t.Errorf("Expected body 'hello world', got: '%s'", string(body))
+		}
+	})
+
+	t.Run("Query string is not a part of file name", func(t *testing.T) {
+		req, err := NewFileRequest("foo?download=1&download=2")
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got: %d", resp.StatusCode)
+		}
+	})
+
+	invalidTargets := map[string]string{
+		"Encoded traversal in file name": "/files/..%2Ffoo",
+		"Encoded slash in file name":     "/files/a%2Fb",
+		"Encoded NUL in file name":       "/files/foo%00.txt",
+		"Invalid percent-encoding":       "/echo/%zz",
+	}
+
+	for name, target := range invalidTargets {
+		t.Run(name+" returns 400", func(t *testing.T) {
+			conn := DialServer(t)
+			defer conn.Close()
+
+			fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: localhost\r\n\r\n", target)
+
+			resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got: %d", resp.StatusCode)
+			}
+		})
+	}
+}
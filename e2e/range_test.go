@@ -0,0 +1,141 @@
+package e2e
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExecuteRangeRequest(t *testing.T, rangeHeader string, ifRange string) (*http.Response, string) {
+	req, err := NewFileRequest("foo")
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Range", rangeHeader)
+	if ifRange != "" {
+		req.Header.Set("If-Range", ifRange)
+	}
+
+	resp, err := ExecuteRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+
+	return resp, string(body)
+}
+
+func TestRange(t *testing.T) {
+	// Content of file foo is "Hello, World!"
+	singleRangeCases := []struct {
+		rangeHeader  string
+		expectedBody string
+		contentRange string
+	}{
+		{"bytes=0-4", "Hello", "bytes 0-4/13"},
+		{"bytes=7-", "World!", "bytes 7-12/13"},
+		{"bytes=-6", "World!", "bytes 7-12/13"},
+		{"bytes=7-100", "World!", "bytes 7-12/13"},
+	}
+
+	for _, testCase := range singleRangeCases {
+		t.Run("Single range "+testCase.rangeHeader, func(t *testing.T) {
+			resp, body := ExecuteRangeRequest(t, testCase.rangeHeader, "")
+
+			if resp.StatusCode != http.StatusPartialContent {
+				t.Errorf("Expected status 206, got: %d", resp.StatusCode)
+			}
+			if body != testCase.expectedBody {
+				t.Errorf("Expected body '%s', got: '%s'", testCase.expectedBody, body)
+			}
+			if contentRange := resp.Header.Get("Content-Range"); contentRange != testCase.contentRange {
+				t.Errorf("Expected Content-Range '%s', got: '%s'", testCase.contentRange, contentRange)
+			}
+		})
+	}
+
+	t.Run("Multiple ranges are sent as multipart/byteranges", func(t *testing.T) {
+		resp, body := ExecuteRangeRequest(t, "bytes=0-4, 7-11", "")
+
+		if resp.StatusCode != http.StatusPartialContent {
+			t.Fatalf("Expected status 206, got: %d", resp.StatusCode)
+		}
+
+		mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/byteranges" {
+			t.Fatalf("Expected multipart/byteranges, got: '%s'", resp.Header.Get("Content-Type"))
+		}
+
+		reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+		expectedParts := []struct{ content, contentRange string }{
+			{"Hello", "bytes 0-4/13"},
+			{"World", "bytes 7-11/13"},
+		}
+		for _, expected := range expectedParts {
+			part, err := reader.NextPart()
+			if err != nil {
+				t.Fatalf("Failed to read part: %v", err)
+			}
+			content, _ := io.ReadAll(part)
+			if string(content) != expected.content {
+				t.Errorf("Expected part '%s', got: '%s'", expected.content, string(content))
+			}
+			if contentRange := part.Header.Get("Content-Range"); contentRange != expected.contentRange {
+				t.Errorf("Expected Content-Range '%s', got: '%s'", expected.contentRange, contentRange)
+			}
+		}
+		if _, err := reader.NextPart(); err != io.EOF {
+			t.Errorf("Expected exactly two parts, got: %v", err)
+		}
+	})
+
+	t.Run("Unsatisfiable range returns 416", func(t *testing.T) {
+		resp, _ := ExecuteRangeRequest(t, "bytes=100-200", "")
+
+		if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+			t.Errorf("Expected status 416, got: %d", resp.StatusCode)
+		}
+		if contentRange := resp.Header.Get("Content-Range"); contentRange != "bytes */13" {
+			t.Errorf("Expected Content-Range 'bytes */13', got: '%s'", contentRange)
+		}
+	})
+
+	t.Run("Malformed range is ignored", func(t *testing.T) {
+		resp, body := ExecuteRangeRequest(t, "bytes=abc", "")
+
+		if resp.StatusCode != http.StatusOK || body != "Hello, World!" {
+			t.Errorf("Expected whole file with status 200, got %d: '%s'", resp.StatusCode, body)
+		}
+		if acceptRanges := resp.Header.Get("Accept-Ranges"); acceptRanges != "bytes" {
+			t.Errorf("Expected 'Accept-Ranges: bytes', got: '%s'", acceptRanges)
+		}
+	})
+
+	t.Run("If-Range with current date returns range", func(t *testing.T) {
+		resp, _ := ExecuteRangeRequest(t, "bytes=0-0", "")
+		lastModified := resp.Header.Get("Last-Modified")
+
+		resp, body := ExecuteRangeRequest(t, "bytes=0-4", lastModified)
+		if resp.StatusCode != http.StatusPartialContent || body != "Hello" {
+			t.Errorf("Expected 'Hello' with status 206, got %d: '%s'", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("If-Range with outdated date returns whole file", func(t *testing.T) {
+		outdated := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+
+		resp, body := ExecuteRangeRequest(t, "bytes=0-4", outdated)
+		if resp.StatusCode != http.StatusOK || body != "Hello, World!" {
+			t.Errorf("Expected whole file with status 200, got %d: '%s'", resp.StatusCode, body)
+		}
+	})
+}
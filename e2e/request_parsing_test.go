@@ -0,0 +1,110 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestRequestParsing(t *testing.T) {
+	t.Run("Large headers are read completely", func(t *testing.T) {
+		req, err := http.NewRequest("GET", Config.GetServerURL("/user-agent"), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		userAgent := strings.Repeat("agent", 1000)
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("X-Padding", strings.Repeat("x", 4096))
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		if string(body) != userAgent {
+			t.Errorf("Expected user agent of %d bytes, got %d bytes", len(userAgent), len(body))
+		}
+	})
+
+	t.Run("Large upload is saved without truncation", func(t *testing.T) {
+		filename := "test-large-upload.txt"
+		t.Cleanup(func() {
+			os.Remove(path.Join(Config.Directory, filename))
+		})
+
+		content := strings.Repeat("0123456789", 100000)
+		req, err := NewFileRequestWithMethod("POST", filename, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("Failed to create upload request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to upload file: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got: %d", resp.StatusCode)
+		}
+
+		saved, err := os.ReadFile(path.Join(Config.Directory, filename))
+		if err != nil {
+			t.Fatalf("Failed to read uploaded file: %v", err)
+		}
+		if string(saved) != content {
+			t.Errorf("Expected file of %d bytes, got %d bytes", len(content), len(saved))
+		}
+	})
+
+	t.Run("Pipelined requests are answered in order", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		fmt.Fprint(conn, "GET /echo/one HTTP/1.1\r\nHost: localhost\r\n\r\n"+
+			"GET /echo/two HTTP/1.1\r\nHost: localhost\r\n\r\n")
+
+		for _, expected := range []string{"one", "two"} {
+			_, body := ReadRawResponse(t, reader)
+			if body != expected {
+				t.Errorf("Expected body '%s', got: '%s'", expected, body)
+			}
+		}
+	})
+
+	malformedRequests := map[string]string{
+		"Request line without target": "GET\r\n\r\n",
+		"Header without colon":        "GET / HTTP/1.1\r\nHost localhost\r\n\r\n",
+		"Space before colon":          "GET / HTTP/1.1\r\nHost : localhost\r\n\r\n",
+		"Invalid Content-Length":      "POST /files/x HTTP/1.1\r\nContent-Length: ten\r\n\r\n",
+		"Signed Content-Length":       "POST /files/x HTTP/1.1\r\nContent-Length: +3\r\n\r\nabc",
+		"Unknown protocol":            "GET / HTTP/2.0\r\n\r\n",
+	}
+
+	for name, rawRequest := range malformedRequests {
+		t.Run(name+" returns 400", func(t *testing.T) {
+			conn := DialServer(t)
+			defer conn.Close()
+
+			fmt.Fprint(conn, rawRequest)
+
+			resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got: %d", resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	t.Run("Unsupported method returns 405 with Allow header", func(t *testing.T) {
+		req, err := NewFileRequestWithMethod("TRACE", "foo", nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got: %d", resp.StatusCode)
+		}
+
+		expectedAllow := "DELETE, GET, HEAD, OPTIONS, PATCH, POST, PUT"
+		if allow := resp.Header.Get("Allow"); allow != expectedAllow {
+			t.Errorf("Expected Allow '%s', got: '%s'", expectedAllow, allow)
+		}
+	})
+
+	t.Run("OPTIONS lists allowed methods", func(t *testing.T) {
+		req, err := http.NewRequest("OPTIONS", Config.GetServerURL("/echo/abc"), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got: %d", resp.StatusCode)
+		}
+
+		expectedAllow := "GET, HEAD, OPTIONS"
+		if allow := resp.Header.Get("Allow"); allow != expectedAllow {
+			t.Errorf("Expected Allow '%s', got: '%s'", expectedAllow, allow)
+		}
+	})
+
+	t.Run("HEAD is served by GET route without body", func(t *testing.T) {
+		conn := DialServer(t)
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		fmt.Fprint(conn, "HEAD /echo/abc HTTP/1.1\r\nHost: localhost\r\n\r\nGET /echo/next HTTP/1.1\r\nHost: localhost\r\n\r\n")
+
+		resp, err := http.ReadResponse(reader, &http.Request{Method: "HEAD"})
+		if err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got: %d", resp.StatusCode)
+		}
+		if resp.ContentLength != 3 {
+			t.Errorf("Expected Content-Length 3, got: %d", resp.ContentLength)
+		}
+
+		// Response to the next request must follow headers right away
+		_, body := ReadRawResponse(t, reader)
+		if body != "next" {
+			t.Errorf("Expected body 'next', got: '%s'", body)
+		}
+	})
+
+	t.Run("Route parameters capture path segments", func(t *testing.T) {
+		req, err := http.NewRequest("GET", Config.GetServerURL("/echo/a/b/c"), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != "a/b/c" {
+			t.Errorf("Expected body 'a/b/c', got: '%s'", string(body))
+		}
+	})
+
+	t.Run("Middlewares add Server-Timing header", func(t *testing.T) {
+		req, err := http.NewRequest("GET", Config.GetServerURL("/user-agent"), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if timing := resp.Header.Get("Server-Timing"); !strings.HasPrefix(timing, "app;dur=") {
+			t.Errorf("Expected Server-Timing header, got: '%s'", timing)
+		}
+	})
+
+	t.Run("Unknown path returns 404", func(t *testing.T) {
+		req, err := http.NewRequest("GET", Config.GetServerURL("/unknown"), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404, got: %d", resp.StatusCode)
+		}
+	})
+}
@@ -3,9 +3,12 @@ package e2e
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -17,10 +20,12 @@ type TestConfig struct {
 	Directory  string
 }
 
+// Limit of upload size the server is started with
+const MaxUploadSize = 2 * 1024 * 1024
+
 var Config = TestConfig{
 	ServerPort: 4222,
 	ServerHost: "localhost",
-	Directory:  "/Users/user/Code/own/golang/learning-go-http-server/files",
 }
 
 // Helper function to get server URL
@@ -28,7 +33,7 @@ func (c TestConfig) GetServerURL(path string) string {
 	return fmt.Sprintf("http://%s:%d%s", c.ServerHost, c.ServerPort, path)
 }
 
-func logServerOutput(cmd *exec.Cmd, rootDir string) {
+func logServerOutput(cmd *exec.Cmd, rootDir string, logName string) {
 	// Create logs directory if it doesn't exist
 	logDir := filepath.Join(rootDir, "logs", "e2e")
 	if err := os.MkdirAll(logDir, 0755); err != nil {
@@ -36,7 +41,7 @@ func logServerOutput(cmd *exec.Cmd, rootDir string) {
 	}
 
 	// Open log file
-	file, err := os.OpenFile(filepath.Join(logDir, "server.log"),
+	file, err := os.OpenFile(filepath.Join(logDir, logName),
 		os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		panic("Failed to open server log file: " + err.Error())
@@ -47,9 +52,15 @@ func logServerOutput(cmd *exec.Cmd, rootDir string) {
 	cmd.Stderr = file
 }
 
-var serverProcess *os.Process
+// ServerProcess is a server started by tests
+type ServerProcess struct {
+	cmd  *exec.Cmd
+	done chan error
+}
 
-func TestMain(m *testing.M) {
+// StartServer starts server from the project root with given arguments and
+// waits until it accepts connections on port.
+func StartServer(port int, logName string, args ...string) *ServerProcess {
 	// Get current working directory
 	wd, err := os.Getwd()
 	if err != nil {
@@ -57,46 +68,84 @@ func TestMain(m *testing.M) {
 	}
 
 	// Start the server process
-	cmd := exec.Command("./your_server.sh",
-		"--directory", Config.Directory,
-		"--port", fmt.Sprintf("%d", Config.ServerPort))
+	cmd := exec.Command("./your_server.sh", append([]string{"--port", strconv.Itoa(port)}, args...)...)
 
 	// Set working directory to project root
 	cmd.Dir = filepath.Dir(wd) // go up one level from e2e directory
-	log.Println("Working directory:", cmd.Dir)
 
 	// Setup logging
-	logServerOutput(cmd, cmd.Dir)
+	logServerOutput(cmd, cmd.Dir, logName)
 
 	err = cmd.Start()
 	if err != nil {
 		panic("Failed to start server: " + err.Error())
 	}
 
-	serverProcess = cmd.Process
-
-	log.Println("Server process ID:", serverProcess.Pid)
+	log.Println("Server process ID:", cmd.Process.Pid)
 
 	// Create error channel to handle process errors
-	done := make(chan error, 1)
+	server := &ServerProcess{cmd: cmd, done: make(chan error, 1)}
 	go func() {
-		done <- cmd.Wait()
+		server.done <- cmd.Wait()
 	}()
 
-	// Wait for either server to start or error
+	// Wait for either server to start or error. Server is compiled before
+	// start, so it is polled until it accepts connections.
+	deadline := time.After(30 * time.Second)
+	for {
+		select {
+		case err := <-server.done:
+			panic(fmt.Sprintf("Server failed to start: %v", err))
+		case <-deadline:
+			panic("Server hasn't started in time")
+		case <-time.After(100 * time.Millisecond):
+			conn, err := net.Dial("tcp", net.JoinHostPort(Config.ServerHost, strconv.Itoa(port)))
+			if err == nil {
+				conn.Close()
+				return server
+			}
+		}
+	}
+}
+
+// Stop asks server to shut down gracefully and waits until it exits. Error of
+// the process is returned, i.e. *exec.ExitError for non-zero exit status.
+func (server *ServerProcess) Stop(timeout time.Duration) error {
+	if err := server.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		return err
+	}
+
 	select {
-	case err := <-done:
-		panic(fmt.Sprintf("Server failed to start: %v", err))
-	case <-time.After(1 * time.Second):
-		// Server started successfully
+	case err := <-server.done:
+		return err
+	case <-time.After(timeout):
+		server.cmd.Process.Kill()
+		return fmt.Errorf("server hasn't stopped in %s", timeout)
 	}
+}
+
+func TestMain(m *testing.M) {
+	// Get current working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		panic("Failed to get working directory: " + err.Error())
+	}
+
+	// Serve files from the repository's files folder
+	Config.Directory = filepath.Join(filepath.Dir(wd), "files")
+	log.Println("Working directory:", filepath.Dir(wd))
+
+	server := StartServer(Config.ServerPort, "server.log",
+		"--directory", Config.Directory,
+		"--directory-index",
+		"--max-upload-size", strconv.Itoa(MaxUploadSize))
 
 	// Run tests
 	code := m.Run()
 
-	// Cleanup: kill the server process
-	if err := serverProcess.Kill(); err != nil {
-		panic("Failed to kill server: " + err.Error())
+	// Cleanup: stop the server process
+	if err := server.Stop(10 * time.Second); err != nil {
+		panic("Failed to stop server: " + err.Error())
 	}
 
 	os.Exit(code)
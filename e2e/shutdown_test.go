@@ -0,0 +1,91 @@
+package e2e
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func dialPort(t *testing.T, port int) net.Conn {
+	conn, err := net.Dial("tcp", net.JoinHostPort(Config.ServerHost, strconv.Itoa(port)))
+	if err != nil {
+		t.Fatalf("Failed to connect to server: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	t.Run("Request being served finishes and idle connection is closed", func(t *testing.T) {
+		port := Config.ServerPort + 1
+		server := StartServer(port, "shutdown-server.log", "--directory", Config.Directory)
+
+		filename := "test-shutdown-upload.txt"
+		t.Cleanup(func() {
+			os.Remove(path.Join(Config.Directory, filename))
+		})
+
+		idleConn := dialPort(t, port)
+		defer idleConn.Close()
+		fmt.Fprint(idleConn, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+		idleReader := bufio.NewReader(idleConn)
+		ReadRawResponse(t, idleReader)
+
+		uploadConn := dialPort(t, port)
+		defer uploadConn.Close()
+		fmt.Fprintf(uploadConn, "POST /files/%s HTTP/1.1\r\nHost: localhost\r\nContent-Length: 10\r\n\r\nhello", filename)
+		// Let the server start reading the body
+		time.Sleep(200 * time.Millisecond)
+
+		stopped := make(chan error, 1)
+		go func() {
+			stopped <- server.Stop(10 * time.Second)
+		}()
+
+		if _, err := idleReader.ReadByte(); !errors.Is(err, io.EOF) {
+			t.Errorf("Expected idle connection to be closed, got: %v", err)
+		}
+
+		fmt.Fprint(uploadConn, "world")
+		resp, _ := ReadRawResponse(t, bufio.NewReader(uploadConn))
+		if resp.StatusCode != http.StatusCreated {
+			t.Errorf("Expected status 201, got: %d", resp.StatusCode)
+		}
+		if !resp.Close {
+			t.Errorf("Expected connection to be closed after response")
+		}
+
+		if err := <-stopped; err != nil {
+			t.Errorf("Expected server to exit cleanly, got: %v", err)
+		}
+		if _, err := net.Dial("tcp", net.JoinHostPort(Config.ServerHost, strconv.Itoa(port))); err == nil {
+			t.Errorf("Expected server to stop accepting connections")
+		}
+	})
+
+	t.Run("Connections are closed when drain timeout expires", func(t *testing.T) {
+		port := Config.ServerPort + 2
+		server := StartServer(port, "shutdown-timeout-server.log",
+			"--directory", Config.Directory, "--drain-timeout", "200ms")
+
+		conn := dialPort(t, port)
+		defer conn.Close()
+		// Body never completes
+		fmt.Fprint(conn, "POST /files/test-shutdown-stalled.txt HTTP/1.1\r\nHost: localhost\r\nContent-Length: 10\r\n\r\nhello")
+		time.Sleep(200 * time.Millisecond)
+
+		var exitErr *exec.ExitError
+		if err := server.Stop(10 * time.Second); !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			t.Errorf("Expected server to exit with status 1, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package e2e
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeouts(t *testing.T) {
+	port := Config.ServerPort + 3
+	server := StartServer(port, "timeouts-server.log",
+		"--directory", Config.Directory,
+		"--header-timeout", "300ms",
+		"--body-timeout", "300ms",
+		"--idle-timeout", "300ms")
+	t.Cleanup(func() {
+		server.Stop(10 * time.Second)
+	})
+
+	t.Run("Connection without request is closed", func(t *testing.T) {
+		conn := dialPort(t, port)
+		defer conn.Close()
+
+		if _, err := bufio.NewReader(conn).ReadByte(); !errors.Is(err, io.EOF) {
+			t.Errorf("Expected connection to be closed, got: %v", err)
+		}
+	})
+
+	t.Run("Incomplete headers return 408", func(t *testing.T) {
+		conn := dialPort(t, port)
+		defer conn.Close()
+
+		fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n")
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusRequestTimeout {
+			t.Errorf("Expected status 408, got: %d", resp.StatusCode)
+		}
+		if !resp.Close {
+			t.Errorf("Expected connection to be closed")
+		}
+	})
+
+	t.Run("Stalled body returns 408", func(t *testing.T) {
+		conn := dialPort(t, port)
+		defer conn.Close()
+
+		fmt.Fprint(conn, "POST /echo HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/json\r\nContent-Length: 10\r\n\r\n{")
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusRequestTimeout {
+			t.Errorf("Expected status 408, got: %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Idle keep-alive connection is closed", func(t *testing.T) {
+		conn := dialPort(t, port)
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+		if resp, _ := ReadRawResponse(t, reader); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+
+		if _, err := reader.ReadByte(); !errors.Is(err, io.EOF) {
+			t.Errorf("Expected connection to be closed, got: %v", err)
+		}
+	})
+
+	t.Run("Timeouts are counted in metrics", func(t *testing.T) {
+		conn := dialPort(t, port)
+		defer conn.Close()
+
+		fmt.Fprint(conn, "GET /metrics HTTP/1.1\r\nHost: localhost\r\n\r\n")
+		resp, body := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+
+		for _, expected := range []string{
+			"http_header_read_timeouts_total 1\n",
+			"http_body_read_timeouts_total 1\n",
+			"http_idle_timeouts_total 2\n",
+		} {
+			if !strings.Contains(body, expected) {
+				t.Errorf("Expected metrics to contain '%s', got:\n%s", strings.TrimSpace(expected), body)
+			}
+		}
+	})
+}
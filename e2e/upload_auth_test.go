@@ -0,0 +1,100 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadAuthentication(t *testing.T) {
+	port := Config.ServerPort + 4
+	server := StartServer(port, "upload-auth-server.log",
+		"--directory", Config.Directory,
+		"--upload-credentials", "uploader:secret")
+	t.Cleanup(func() {
+		server.Stop(10 * time.Second)
+	})
+
+	filename := "test-upload-auth.txt"
+	filePath := path.Join(Config.Directory, filename)
+	t.Cleanup(func() {
+		os.Remove(filePath)
+	})
+
+	upload := func(t *testing.T, username string, password string) *http.Response {
+		url := fmt.Sprintf("http://%s:%d/files/%s", Config.ServerHost, port, filename)
+		req, err := http.NewRequest("POST", url, strings.NewReader("secret content"))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("Upload without credentials returns 401", func(t *testing.T) {
+		resp := upload(t, "", "")
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got: %d", resp.StatusCode)
+		}
+		if challenge := resp.Header.Get("WWW-Authenticate"); !strings.HasPrefix(challenge, `Basic realm="files"`) {
+			t.Errorf("Expected Basic challenge of realm 'files', got: '%s'", challenge)
+		}
+		if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+			t.Errorf("Expected file not to be created, got: %v", err)
+		}
+	})
+
+	t.Run("Upload with wrong password returns 401", func(t *testing.T) {
+		if resp := upload(t, "uploader", "guess"); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got: %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Unauthorized upload is refused before body is sent", func(t *testing.T) {
+		conn := dialPort(t, port)
+		defer conn.Close()
+
+		// Body is never sent, so server must not wait for it
+		fmt.Fprintf(conn, "PUT /files/%s HTTP/1.1\r\nHost: localhost\r\nContent-Length: 1000\r\nExpect: 100-continue\r\n\r\n", filename)
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got: %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Upload with credentials returns 201", func(t *testing.T) {
+		if resp := upload(t, "uploader", "secret"); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got: %d", resp.StatusCode)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil || string(content) != "secret content" {
+			t.Errorf("Expected uploaded content, got: '%s' (%v)", content, err)
+		}
+	})
+
+	t.Run("Download doesn't need credentials", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://%s:%d/files/%s", Config.ServerHost, port, filename))
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got: %d", resp.StatusCode)
+		}
+	})
+}
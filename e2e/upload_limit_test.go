@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func assertNoUploadLeftovers(t *testing.T, filename string) {
+	if _, err := os.Stat(path.Join(Config.Directory, filename)); !os.IsNotExist(err) {
+		t.Errorf("Expected file not to be saved, got: %v", err)
+	}
+
+	entries, err := os.ReadDir(Config.Directory)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".upload-") {
+			t.Errorf("Expected temporary file to be removed, found: %s", entry.Name())
+		}
+	}
+}
+
+func TestUploadLimit(t *testing.T) {
+	t.Run("Upload at the limit is saved", func(t *testing.T) {
+		filename := "test-limit-upload.txt"
+		t.Cleanup(func() {
+			os.Remove(path.Join(Config.Directory, filename))
+		})
+
+		content := strings.Repeat("a", MaxUploadSize)
+		req, err := NewFileRequestWithMethod("POST", filename, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("Failed to create upload request: %v", err)
+		}
+
+		resp, err := ExecuteRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to upload file: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got: %d", resp.StatusCode)
+		}
+		info, err := os.Stat(path.Join(Config.Directory, filename))
+		if err != nil || info.Size() != MaxUploadSize {
+			t.Errorf("Expected file of %d bytes, got: %v", MaxUploadSize, err)
+		}
+	})
+
+	t.Run("Declared length over the limit returns 413 before body is sent", func(t *testing.T) {
+		filename := "test-too-large.txt"
+
+		conn := DialServer(t)
+		defer conn.Close()
+
+		fmt.Fprintf(conn, "POST /files/%s HTTP/1.1\r\nHost: localhost\r\nContent-Length: %d\r\n\r\n",
+			filename, MaxUploadSize+1)
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413, got: %d", resp.StatusCode)
+		}
+		if !resp.Close {
+			t.Errorf("Expected connection to be closed")
+		}
+		assertNoUploadLeftovers(t, filename)
+	})
+
+	t.Run("Chunked body over the limit returns 413", func(t *testing.T) {
+		filename := "test-too-large-chunked.txt"
+
+		conn := DialServer(t)
+		defer conn.Close()
+
+		go fmt.Fprintf(conn, "POST /files/%s HTTP/1.1\r\nHost: localhost\r\nTransfer-Encoding: chunked\r\n\r\n"+
+			"%x\r\n%s\r\n0\r\n\r\n", filename, MaxUploadSize+1, strings.Repeat("a", MaxUploadSize+1))
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413, got: %d", resp.StatusCode)
+		}
+		assertNoUploadLeftovers(t, filename)
+	})
+
+	t.Run("Chunked PATCH over the limit leaves file unchanged", func(t *testing.T) {
+		filename := "test-too-large-patch.txt"
+		filePath := path.Join(Config.Directory, filename)
+		if err := os.WriteFile(filePath, []byte("original"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		t.Cleanup(func() {
+			os.Remove(filePath)
+		})
+
+		conn := DialServer(t)
+		defer conn.Close()
+
+		go fmt.Fprintf(conn, "PATCH /files/%s HTTP/1.1\r\nHost: localhost\r\nTransfer-Encoding: chunked\r\n\r\n"+
+			"%x\r\n%s\r\n0\r\n\r\n", filename, MaxUploadSize+1, strings.Repeat("a", MaxUploadSize+1))
+
+		resp, _ := ReadRawResponse(t, bufio.NewReader(conn))
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413, got: %d", resp.StatusCode)
+		}
+
+		saved, err := os.ReadFile(filePath)
+		if err != nil || string(saved) != "original" {
+			t.Errorf("Expected file to be unchanged, got %d bytes (%v)", len(saved), err)
+		}
+	})
+}